@@ -25,7 +25,7 @@ func parseAndValidateFlags() *config.Config {
 
 	flag.BoolVar(&help, "help", false, "Print this help.")
 	flag.BoolVar(&version, "version", false, "Print version.")
-	flag.BoolVar(&runOnce, "run-once", false, "Run checks only once and exit.")
+	flag.BoolVar(&runOnce, "run-once", false, "Run checks only once and exit. The alarm durations are only tracked across runs with the Redis cache (REDIS_ENABLED=true).")
 	flag.StringVar(&cfgFile, "config", "", "Config file")
 
 	flag.String("settings.kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
@@ -39,11 +39,16 @@ func parseAndValidateFlags() *config.Config {
 	flag.String("settings.apiKey", "", "(REQUIRED) The iLert alert source api key")
 	flag.String("settings.httpAuthorizationKey", "", "The authorization key for ilert AI agent")
 	flag.String("settings.checkInterval", "15s", "The evaluation check interval e.g. resources check")
+	flag.Int64("settings.stateCacheSize", 5000, "The max number of alarm state entries kept in memory without Redis e.g. problem since times and open alerts")
+	flag.String("settings.externalURL", "", "The external URL of the agent http server used for commander endpoints in alerts e.g. https://ilert-kube-agent.example.com")
+	flag.String("settings.scope.labelSelector", "", "Only watch namespaced objects matching the label selector e.g. team=payments")
+	flag.String("settings.scope.fieldSelector", "", "Only watch pods matching the field selector e.g. spec.nodeName=node-1")
 
 	flag.Bool("alarms.cluster.enabled", true, "Enable cluster alarms")
 	flag.String("alarms.cluster.priority", "HIGH", "The cluster alarm alert priority")
 
 	flag.Bool("alarms.pods.enabled", true, "Enable pod alarms")
+	flag.String("alarms.pods.keyStrategy", "pod", "The alert key strategy of the terminate, waiting and restarts pod alarms (pod, container, workload or workloadReason), the other pod alarms always use per pod keys")
 	flag.Bool("alarms.pods.terminate.enabled", true, "Enable pod terminate alarms")
 	flag.String("alarms.pods.terminate.priority", "HIGH", "The pod terminate alarm alert priority")
 	flag.Bool("alarms.pods.waiting.enabled", true, "Enable pod waiting alarms")
@@ -51,6 +56,18 @@ func parseAndValidateFlags() *config.Config {
 	flag.Bool("alarms.pods.restarts.enabled", true, "Enable pod restarts alarms")
 	flag.String("alarms.pods.restarts.priority", "LOW", "The pod waiting alarm alert priority")
 	flag.Int("alarms.pods.restarts.threshold", 10, "Pod restart threshold to alarm")
+	flag.String("alarms.pods.restarts.mode", "absolute", "Pod restarts alarm mode, absolute compares the restart count, rate the restarts within window")
+	flag.String("alarms.pods.restarts.window", "10m", "Pod restarts rate window e.g. 10m")
+	flag.Bool("alarms.pods.pending.enabled", false, "Enable pod pending alarms")
+	flag.String("alarms.pods.pending.priority", "LOW", "The pod pending alarm alert priority")
+	flag.String("alarms.pods.pending.duration", "5m", "The duration a pod can stay unscheduled before alarm e.g. 5m")
+	flag.Bool("alarms.pods.terminating.enabled", false, "Enable pod stuck terminating alarms")
+	flag.String("alarms.pods.terminating.priority", "LOW", "The pod stuck terminating alarm alert priority")
+	flag.String("alarms.pods.terminating.duration", "10m", "The duration a pod deletion can be pending after its grace period before alarm e.g. 10m")
+	flag.Bool("alarms.pods.evictions.enabled", false, "Enable pod eviction and preemption alarms")
+	flag.String("alarms.pods.evictions.priority", "LOW", "The pod eviction and preemption alarm alert priority")
+	flag.Bool("alarms.pods.oomKilled.enabled", false, "Enable pod OOMKilled alarms for restarted containers")
+	flag.String("alarms.pods.oomKilled.priority", "HIGH", "The pod OOMKilled alarm alert priority")
 	flag.Bool("alarms.pods.resources.enabled", true, "Enable pod resources alarms")
 	flag.Bool("alarms.pods.resources.cpu.enabled", true, "Enable pod CPU resources alarms")
 	flag.String("alarms.pods.resources.cpu.priority", "LOW", "The pod CPU resources alarm alert priority")
@@ -58,6 +75,35 @@ func parseAndValidateFlags() *config.Config {
 	flag.Bool("alarms.pods.resources.memory.enabled", true, "Enable pod memory resources alarms")
 	flag.String("alarms.pods.resources.memory.priority", "LOW", "The pod memory resources alarm alert priority")
 	flag.Int("alarms.pods.resources.memory.threshold", 90, "The pod memory resources percentage threshold from 1 to 100")
+	flag.Bool("alarms.pods.resources.requests.enabled", false, "Enable pod resources alarms based on the container requests")
+	flag.String("alarms.pods.resources.requests.priority", "LOW", "The pod resources requests alarm alert priority")
+	flag.Int("alarms.pods.resources.requests.threshold", 100, "The pod resources requests utilization percentage threshold from 1 to 1000")
+	flag.String("alarms.pods.resources.for", "0s", "The duration the pod resources threshold has to be exceeded before alarm e.g. 5m")
+	flag.Int("alarms.pods.resources.hysteresis", 0, "The percentage the pod resources usage has to drop below the threshold before it is healthy again")
+	flag.Bool("alarms.pods.resources.prediction.enabled", false, "Enable pod memory exhaustion prediction alarms")
+	flag.String("alarms.pods.resources.prediction.priority", "LOW", "The pod memory exhaustion prediction alarm alert priority")
+	flag.String("alarms.pods.resources.prediction.horizon", "30m", "The horizon the pod memory usage trend is projected to reach the limit within before alarm e.g. 30m")
+	flag.Int("alarms.pods.resources.prediction.samples", 20, "The number of pod memory usage samples the trend is fitted to from 3 to 1000")
+	flag.Bool("alarms.pods.initContainers.enabled", false, "Enable pod init container alarms")
+	flag.Bool("alarms.pods.initContainers.terminate.enabled", true, "Enable pod init container terminate alarms")
+	flag.String("alarms.pods.initContainers.terminate.priority", "HIGH", "The pod init container terminate alarm alert priority")
+	flag.Bool("alarms.pods.initContainers.waiting.enabled", true, "Enable pod init container waiting alarms")
+	flag.String("alarms.pods.initContainers.waiting.priority", "LOW", "The pod init container waiting alarm alert priority")
+	flag.Bool("alarms.pods.initContainers.restarts.enabled", true, "Enable pod init container restarts alarms")
+	flag.String("alarms.pods.initContainers.restarts.priority", "LOW", "The pod init container restarts alarm alert priority")
+	flag.Int("alarms.pods.initContainers.restarts.threshold", 10, "Pod init container restart threshold to alarm")
+	flag.String("alarms.pods.initContainers.restarts.mode", "absolute", "Pod init container restarts alarm mode, absolute compares the restart count, rate the restarts within window")
+	flag.String("alarms.pods.initContainers.restarts.window", "10m", "Pod init container restarts rate window e.g. 10m")
+	flag.Bool("alarms.pods.ephemeralContainers.enabled", false, "Enable pod ephemeral container alarms")
+	flag.Bool("alarms.pods.ephemeralContainers.terminate.enabled", true, "Enable pod ephemeral container terminate alarms")
+	flag.String("alarms.pods.ephemeralContainers.terminate.priority", "LOW", "The pod ephemeral container terminate alarm alert priority")
+	flag.Bool("alarms.pods.ephemeralContainers.waiting.enabled", true, "Enable pod ephemeral container waiting alarms")
+	flag.String("alarms.pods.ephemeralContainers.waiting.priority", "LOW", "The pod ephemeral container waiting alarm alert priority")
+	flag.Bool("alarms.pods.ephemeralContainers.restarts.enabled", true, "Enable pod ephemeral container restarts alarms")
+	flag.String("alarms.pods.ephemeralContainers.restarts.priority", "LOW", "The pod ephemeral container restarts alarm alert priority")
+	flag.Int("alarms.pods.ephemeralContainers.restarts.threshold", 10, "Pod ephemeral container restart threshold to alarm")
+	flag.String("alarms.pods.ephemeralContainers.restarts.mode", "absolute", "Pod ephemeral container restarts alarm mode, absolute compares the restart count, rate the restarts within window")
+	flag.String("alarms.pods.ephemeralContainers.restarts.window", "10m", "Pod ephemeral container restarts rate window e.g. 10m")
 
 	flag.Bool("alarms.nodes.enabled", true, "Enable node alarms")
 	flag.Bool("alarms.nodes.terminate.enabled", true, "Enable node terminate alarms")
@@ -69,6 +115,87 @@ func parseAndValidateFlags() *config.Config {
 	flag.Bool("alarms.nodes.resources.memory.enabled", true, "Enable node memory resources alarms")
 	flag.String("alarms.nodes.resources.memory.priority", "LOW", "The node memory resources alarm alert priority")
 	flag.Int("alarms.nodes.resources.memory.threshold", 90, "The node memory resources percentage threshold from 1 to 100")
+	flag.String("alarms.nodes.resources.for", "0s", "The duration the node resources threshold has to be exceeded before alarm e.g. 5m")
+	flag.Int("alarms.nodes.resources.hysteresis", 0, "The percentage the node resources usage has to drop below the threshold before it is healthy again")
+	flag.Bool("alarms.nodes.resources.prediction.enabled", false, "Enable node memory exhaustion prediction alarms")
+	flag.String("alarms.nodes.resources.prediction.priority", "LOW", "The node memory exhaustion prediction alarm alert priority")
+	flag.String("alarms.nodes.resources.prediction.horizon", "30m", "The horizon the node memory usage trend is projected to reach the limit within before alarm e.g. 30m")
+	flag.Int("alarms.nodes.resources.prediction.samples", 20, "The number of node memory usage samples the trend is fitted to from 3 to 1000")
+	flag.Bool("alarms.nodes.conditions.enabled", false, "Enable node condition alarms")
+	flag.String("alarms.nodes.conditions.priority", "HIGH", "The default node condition alarm alert priority")
+	flag.String("alarms.nodes.conditions.duration", "1m", "The default duration a node condition must last before alarm e.g. 1m")
+
+	flag.Bool("alarms.namespaces.enabled", false, "Enable namespace alarms")
+	flag.Bool("alarms.namespaces.terminating.enabled", true, "Enable namespace stuck terminating alarms")
+	flag.String("alarms.namespaces.terminating.priority", "LOW", "The namespace stuck terminating alarm alert priority")
+	flag.String("alarms.namespaces.terminating.duration", "30m", "The duration a namespace can be terminating before alarm e.g. 30m")
+
+	flag.Bool("alarms.events.enabled", false, "Enable kubernetes warning events alarms")
+
+	flag.Bool("alarms.jobs.enabled", false, "Enable job and cronjob alarms")
+	flag.Bool("alarms.jobs.failed.enabled", true, "Enable failed job alarms")
+	flag.String("alarms.jobs.failed.priority", "HIGH", "The failed job alarm alert priority")
+	flag.Bool("alarms.jobs.missedSchedule.enabled", true, "Enable cronjob missed schedule alarms")
+	flag.String("alarms.jobs.missedSchedule.priority", "LOW", "The cronjob missed schedule alarm alert priority")
+	flag.String("alarms.jobs.missedSchedule.duration", "10m", "The tolerated delay of a scheduled cronjob run before alarm e.g. 10m")
+	flag.Bool("alarms.jobs.longRunning.enabled", true, "Enable long running job alarms")
+	flag.String("alarms.jobs.longRunning.priority", "LOW", "The long running job alarm alert priority")
+	flag.String("alarms.jobs.longRunning.duration", "1h", "The duration a job can run before alarm e.g. 1h")
+
+	flag.Bool("alarms.workloads.enabled", false, "Enable workload alarms")
+	flag.Bool("alarms.workloads.deployments.enabled", true, "Enable deployment alarms")
+	flag.String("alarms.workloads.deployments.priority", "HIGH", "The deployment alarm alert priority")
+	flag.Int("alarms.workloads.deployments.threshold", 1, "The deployment unavailable replicas threshold to alarm")
+	flag.String("alarms.workloads.deployments.duration", "10m", "The duration deployment replicas can be unavailable before alarm e.g. 10m")
+	flag.Bool("alarms.workloads.statefulSets.enabled", true, "Enable statefulset alarms")
+	flag.String("alarms.workloads.statefulSets.priority", "HIGH", "The statefulset alarm alert priority")
+	flag.Int("alarms.workloads.statefulSets.threshold", 1, "The statefulset unavailable replicas threshold to alarm")
+	flag.String("alarms.workloads.statefulSets.duration", "10m", "The duration statefulset replicas can be unavailable before alarm e.g. 10m")
+	flag.Bool("alarms.workloads.daemonSets.enabled", true, "Enable daemonset alarms")
+	flag.String("alarms.workloads.daemonSets.priority", "LOW", "The daemonset alarm alert priority")
+	flag.Int("alarms.workloads.daemonSets.threshold", 1, "The daemonset unavailable replicas threshold to alarm")
+	flag.String("alarms.workloads.daemonSets.duration", "10m", "The duration daemonset replicas can be unavailable before alarm e.g. 10m")
+
+	flag.Bool("alarms.autoscalers.enabled", false, "Enable horizontal pod autoscaler alarms")
+	flag.String("alarms.autoscalers.priority", "LOW", "The horizontal pod autoscaler alarm alert priority")
+	flag.String("alarms.autoscalers.duration", "15m", "The duration an autoscaler can be saturated or unable to scale before alarm e.g. 15m")
+
+	flag.Bool("alarms.volumes.enabled", false, "Enable persistent volume claim and volume usage alarms")
+	flag.String("alarms.volumes.checkInterval", "5m", "The interval of the volume usage checks, every check reads the kubelet stats of all nodes e.g. 5m")
+	flag.Bool("alarms.volumes.pending.enabled", true, "Enable pending persistent volume claim alarms")
+	flag.String("alarms.volumes.pending.priority", "LOW", "The pending persistent volume claim alarm alert priority")
+	flag.String("alarms.volumes.pending.duration", "10m", "The duration a persistent volume claim can be pending before alarm e.g. 10m")
+	flag.Bool("alarms.volumes.lost.enabled", true, "Enable lost persistent volume claim alarms")
+	flag.String("alarms.volumes.lost.priority", "HIGH", "The lost persistent volume claim alarm alert priority")
+	flag.Bool("alarms.volumes.usage.enabled", true, "Enable volume usage alarms")
+	flag.String("alarms.volumes.usage.priority", "HIGH", "The volume usage alarm alert priority")
+	flag.Int("alarms.volumes.usage.threshold", 90, "The volume usage threshold in percentage for alarm")
+	flag.Bool("alarms.volumes.inodes.enabled", true, "Enable volume inodes usage alarms")
+	flag.String("alarms.volumes.inodes.priority", "HIGH", "The volume inodes usage alarm alert priority")
+	flag.Int("alarms.volumes.inodes.threshold", 90, "The volume inodes usage threshold in percentage for alarm")
+
+	flag.Bool("alarms.services.enabled", false, "Enable service without ready endpoints alarms")
+	flag.String("alarms.services.priority", "HIGH", "The service without ready endpoints alarm alert priority")
+	flag.String("alarms.services.duration", "5m", "The duration a service can have no ready endpoints before alarm e.g. 5m")
+	flag.String("alarms.services.labelSelector", "", "Only alarm for services matching the label selector e.g. tier=frontend")
+
+	flag.Bool("alarms.certificates.enabled", false, "Enable TLS certificate expiry alarms")
+	flag.Bool("alarms.certificates.onlyIngressReferenced", false, "Only check TLS secrets referenced by ingresses")
+	flag.String("alarms.certificates.checkInterval", "1h", "The interval of the TLS certificate checks, every check lists all tls secrets e.g. 1h")
+	flag.Bool("alarms.certificates.warning.enabled", true, "Enable TLS certificate expiry warning alarms")
+	flag.String("alarms.certificates.warning.priority", "LOW", "The TLS certificate expiry warning alarm alert priority")
+	flag.String("alarms.certificates.warning.duration", "720h", "The remaining certificate validity to alarm with the warning priority e.g. 720h")
+	flag.Bool("alarms.certificates.critical.enabled", true, "Enable TLS certificate expiry critical alarms")
+	flag.String("alarms.certificates.critical.priority", "HIGH", "The TLS certificate expiry critical alarm alert priority")
+	flag.String("alarms.certificates.critical.duration", "168h", "The remaining certificate validity to alarm with the critical priority e.g. 168h")
+
+	flag.Bool("alarms.quotas.enabled", false, "Enable resource quota alarms")
+	flag.Bool("alarms.quotas.usage.enabled", true, "Enable resource quota usage alarms")
+	flag.String("alarms.quotas.usage.priority", "LOW", "The resource quota usage alarm alert priority")
+	flag.Int("alarms.quotas.usage.threshold", 90, "The resource quota usage percentage threshold from 1 to 100")
+	flag.Bool("alarms.quotas.limitRanges.enabled", true, "Enable limit range alarms")
+	flag.String("alarms.quotas.limitRanges.priority", "LOW", "The limit range alarm alert priority")
+	flag.Int("alarms.quotas.limitRanges.threshold", 90, "The container limit percentage of the limit range max from 1 to 100")
 
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 	pflag.Parse()
@@ -46,7 +46,7 @@ func main() {
 		return
 	}
 
-	cache.Cache.Init()
+	cache.Cache.Init(cfg.Settings.StateCacheSize)
 
 	memoryLimitMB := memory.GetMemoryLimitMB()
 	memory.StartGlobalMonitor(memoryLimitMB)
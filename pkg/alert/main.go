@@ -1,6 +1,7 @@
 package alert
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -20,6 +21,40 @@ var ilertClient *ilert.Client
 const alertEventRateLimitPerMinute = 1
 const resolveEventRateLimitPer30Minute = 1
 
+// alertLabelsTTL keeps the labels of an open alert as long as the problem states of the watcher
+const alertLabelsTTL = 30 * 24 * time.Hour
+
+func getAlertLabelsKey(alertKey string) string {
+	return fmt.Sprintf("state:alert-labels:%s", alertKey)
+}
+
+// getEventLabels stores the labels of an alert and returns them for its resolve, the resolve has to be routed to the
+// api key of the alert even if it is sent for an object that does not exist anymore or a collapsed workload alert
+func getEventLabels(alertKey string, eventType string, labels map[string]string) map[string]string {
+	stateKey := getAlertLabelsKey(alertKey)
+	if eventType == ilert.EventTypes.Alert {
+		if data, err := json.Marshal(labels); err == nil {
+			cache.Cache.State.SetItem(stateKey, string(data), alertLabelsTTL)
+		}
+		return labels
+	}
+	if eventType != ilert.EventTypes.Resolve {
+		return labels
+	}
+
+	value, err := cache.Cache.State.GetItem(stateKey)
+	if err != nil || value == "" {
+		return labels
+	}
+	cache.Cache.State.DeleteItem(stateKey)
+
+	alertLabels := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &alertLabels); err != nil {
+		return labels
+	}
+	return alertLabels
+}
+
 // CreateEvent creates an alert event
 func CreateEvent(
 	cfg *config.Config,
@@ -42,8 +77,10 @@ func CreateEvent(
 		return errors.New("Failed to create an alert event. API key is required")
 	}
 
-	// Split API keys by comma and trim whitespace
-	apiKeys := strings.Split(cfg.Settings.APIKey, ",")
+	labels = getEventLabels(alertKey, eventType, labels)
+
+	// Split the routed API keys by comma and trim whitespace
+	apiKeys := strings.Split(getRouteAPIKeys(cfg, labels), ",")
 	for i, key := range apiKeys {
 		apiKeys[i] = strings.TrimSpace(key)
 	}
@@ -92,7 +129,7 @@ func CreateEvent(
 			APIKey:        apiKey,
 			Priority:      priority,
 			Links:         links,
-			Labels:        labels,
+			Labels:        getSentLabels(labels),
 			Logs:          logs,
 			CustomDetails: customDetails,
 		}
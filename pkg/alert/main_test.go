@@ -0,0 +1,29 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+)
+
+func TestGetEventLabels(t *testing.T) {
+	cache.Cache.Init(100)
+
+	alertLabels := map[string]string{"namespace": "team-a", "podName": "api-0"}
+	resolveLabels := map[string]string{"namespace": "team-a"}
+
+	if got := getEventLabels("team-a/api-0", ilert.EventTypes.Alert, alertLabels); !reflect.DeepEqual(got, alertLabels) {
+		t.Errorf("alert labels = %v, want %v", got, alertLabels)
+	}
+	if got := getEventLabels("team-a/api-0", ilert.EventTypes.Resolve, resolveLabels); !reflect.DeepEqual(got, alertLabels) {
+		t.Errorf("resolve labels = %v, want the alert labels %v", got, alertLabels)
+	}
+	if got := getEventLabels("team-a/api-0", ilert.EventTypes.Resolve, resolveLabels); !reflect.DeepEqual(got, resolveLabels) {
+		t.Errorf("second resolve labels = %v, want %v", got, resolveLabels)
+	}
+	if got := getEventLabels("team-a/api-1", ilert.EventTypes.Resolve, resolveLabels); !reflect.DeepEqual(got, resolveLabels) {
+		t.Errorf("resolve labels without alert = %v, want %v", got, resolveLabels)
+	}
+}
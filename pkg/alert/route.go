@@ -0,0 +1,222 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+// AnnotationAPIKeyRef is the namespace annotation routing its alerts to the api key of a secret in the agent namespace e.g. team-a-ilert/apiKey
+const AnnotationAPIKeyRef = "ilert.com/api-key-ref"
+
+// LabelAPIKey marks a secret in the agent namespace as api key secret, namespace annotations can only reference marked secrets
+// or the secrets of the routes config, everyone allowed to annotate a namespace must not be able to read any secret of the agent namespace
+const LabelAPIKey = "ilert.com/api-key"
+
+// ObjectLabelPrefix prefixes the kubernetes labels of the alerted object in the event labels, they are only matched
+// against the routes and never sent
+const ObjectLabelPrefix = "objectLabel:"
+
+const defaultAPIKeySecretKey = "apiKey"
+const routeCacheTTL = 5 * time.Minute
+
+// routeCacheErrorTTL keeps failed lookups e.g. a missing secret for a short time, every alert would hit the api server otherwise
+const routeCacheErrorTTL = 30 * time.Second
+
+type routeCacheItem struct {
+	value   string
+	err     error
+	expires time.Time
+}
+
+// routeCache keeps the resolved secret api keys and namespace annotations in memory only, the api keys must not end up in the shared cache
+var routeCache = struct {
+	sync.Mutex
+	items map[string]routeCacheItem
+}{items: make(map[string]routeCacheItem)}
+
+func getRouteCacheItem(key string, load func() (string, error)) (string, error) {
+	routeCache.Lock()
+	item, ok := routeCache.items[key]
+	routeCache.Unlock()
+	if ok && time.Now().Before(item.expires) {
+		return item.value, item.err
+	}
+
+	value, err := load()
+	ttl := routeCacheTTL
+	if err != nil {
+		value = ""
+		ttl = routeCacheErrorTTL
+	}
+
+	routeCache.Lock()
+	routeCache.items[key] = routeCacheItem{value: value, err: err, expires: time.Now().Add(ttl)}
+	routeCache.Unlock()
+	return value, err
+}
+
+// parseAPIKeyRef parses the annotation value secret-name/key, the key defaults to apiKey
+func parseAPIKeyRef(value string) config.ConfigSecretKeyRef {
+	name, key, _ := strings.Cut(strings.TrimSpace(value), "/")
+	return config.ConfigSecretKeyRef{Name: name, Key: key}
+}
+
+// getSecretAPIKey returns the api key of the secret, a secret referenced by a namespace annotation has to be marked with LabelAPIKey
+func getSecretAPIKey(cfg *config.Config, ref config.ConfigSecretKeyRef, requireLabel bool) (string, error) {
+	key := ref.Key
+	if key == "" {
+		key = defaultAPIKeySecretKey
+	}
+
+	return getRouteCacheItem(fmt.Sprintf("secret:%s/%s:%t", ref.Name, key, requireLabel), func() (string, error) {
+		secret, err := cfg.KubeClient.CoreV1().Secrets(cfg.Settings.Namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		if requireLabel && secret.GetLabels()[LabelAPIKey] != "true" {
+			return "", fmt.Errorf("secret %s is not labeled with %s=true", ref.Name, LabelAPIKey)
+		}
+		value, ok := secret.Data[key]
+		if !ok || len(value) == 0 {
+			return "", fmt.Errorf("secret %s has no %s key", ref.Name, key)
+		}
+		return strings.TrimSpace(string(value)), nil
+	})
+}
+
+// isRouteSecret reports whether the secret is referenced by the routes config
+func isRouteSecret(cfg *config.Config, name string) bool {
+	for _, route := range cfg.Routes {
+		if route.APIKeyRef.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func getNamespaceAPIKeyRef(cfg *config.Config, namespace string) string {
+	value, err := getRouteCacheItem(fmt.Sprintf("namespace:%s", namespace), func() (string, error) {
+		ns, err := cfg.KubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return ns.GetAnnotations()[AnnotationAPIKeyRef], nil
+	})
+	if err != nil {
+		log.Debug().Err(err).Str("namespace", namespace).Msg("Failed to get namespace api key annotation")
+		return ""
+	}
+	return value
+}
+
+// WithObjectLabels adds the kubernetes labels of the alerted object to the event labels to match them against the routes
+func WithObjectLabels(labels map[string]string, objectLabels map[string]string) map[string]string {
+	for name, value := range objectLabels {
+		labels[ObjectLabelPrefix+name] = value
+	}
+	return labels
+}
+
+// getSentLabels returns the event labels without the object labels
+func getSentLabels(labels map[string]string) map[string]string {
+	sent := make(map[string]string, len(labels))
+	for name, value := range labels {
+		if !strings.HasPrefix(name, ObjectLabelPrefix) {
+			sent[name] = value
+		}
+	}
+	return sent
+}
+
+// hasRouteLabel reports whether the kubernetes labels of the alerted object or, for objects without the label, the event labels
+// contain the route label. The config keys are lower cased by the config loader
+func hasRouteLabel(labels map[string]string, name string, value string) bool {
+	for labelName, labelValue := range labels {
+		if strings.HasPrefix(labelName, ObjectLabelPrefix) && strings.EqualFold(strings.TrimPrefix(labelName, ObjectLabelPrefix), name) {
+			return labelValue == value
+		}
+	}
+	for labelName, labelValue := range labels {
+		if !strings.HasPrefix(labelName, ObjectLabelPrefix) && strings.EqualFold(labelName, name) && labelValue == value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRoute(route config.ConfigRoute, labels map[string]string) bool {
+	if len(route.Namespaces) > 0 {
+		matched := false
+		for _, pattern := range route.Namespaces {
+			if ok, err := path.Match(pattern, labels["namespace"]); err == nil && ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for name, value := range route.Labels {
+		if !hasRouteLabel(labels, name, value) {
+			return false
+		}
+	}
+	return true
+}
+
+type routeRef struct {
+	ref          config.ConfigSecretKeyRef
+	requireLabel bool
+}
+
+// getRouteRefs returns the secrets to take the api key from in order of precedence, the namespace annotation
+// before the first matching route
+func getRouteRefs(cfg *config.Config, labels map[string]string, namespaceRef string) []routeRef {
+	refs := make([]routeRef, 0)
+	if namespaceRef != "" {
+		ref := parseAPIKeyRef(namespaceRef)
+		refs = append(refs, routeRef{ref: ref, requireLabel: !isRouteSecret(cfg, ref.Name)})
+	}
+	for _, route := range cfg.Routes {
+		if matchRoute(route, labels) {
+			refs = append(refs, routeRef{ref: route.APIKeyRef})
+			break
+		}
+	}
+	return refs
+}
+
+// getRouteAPIKeys returns the comma separated api keys of the event, the namespace annotation takes precedence over
+// the first matching route and the configured api keys are the fallback
+func getRouteAPIKeys(cfg *config.Config, labels map[string]string) string {
+	if cfg.KubeClient == nil {
+		return cfg.Settings.APIKey
+	}
+
+	namespaceRef := ""
+	if namespace := labels["namespace"]; namespace != "" {
+		namespaceRef = getNamespaceAPIKeyRef(cfg, namespace)
+	}
+
+	for _, routeRef := range getRouteRefs(cfg, labels, namespaceRef) {
+		ref := routeRef.ref
+		apiKey, err := getSecretAPIKey(cfg, ref, routeRef.requireLabel)
+		if err != nil {
+			log.Warn().Err(err).Str("secret", ref.Name).Msg("Failed to get routed api key, falling back")
+			continue
+		}
+		return apiKey
+	}
+	return cfg.Settings.APIKey
+}
@@ -0,0 +1,156 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		route  config.ConfigRoute
+		labels map[string]string
+		want   bool
+	}{
+		{
+			name:   "empty route matches everything",
+			route:  config.ConfigRoute{},
+			labels: map[string]string{"namespace": "default"},
+			want:   true,
+		},
+		{
+			name:   "namespace glob",
+			route:  config.ConfigRoute{Namespaces: []string{"payments-*"}},
+			labels: map[string]string{"namespace": "payments-prod"},
+			want:   true,
+		},
+		{
+			name:   "namespace glob mismatch",
+			route:  config.ConfigRoute{Namespaces: []string{"payments-*"}},
+			labels: map[string]string{"namespace": "checkout"},
+			want:   false,
+		},
+		{
+			name:   "object label",
+			route:  config.ConfigRoute{Labels: map[string]string{"team": "a"}},
+			labels: WithObjectLabels(map[string]string{"namespace": "default"}, map[string]string{"team": "a"}),
+			want:   true,
+		},
+		{
+			name:   "object label is matched case insensitive against the lower cased config key",
+			route:  config.ConfigRoute{Labels: map[string]string{"teamname": "a"}},
+			labels: WithObjectLabels(map[string]string{}, map[string]string{"teamName": "a"}),
+			want:   true,
+		},
+		{
+			name:   "object label takes precedence over the event label",
+			route:  config.ConfigRoute{Labels: map[string]string{"app": "checkout"}},
+			labels: WithObjectLabels(map[string]string{"app": "checkout"}, map[string]string{"app": "payments"}),
+			want:   false,
+		},
+		{
+			name:   "event label without object label",
+			route:  config.ConfigRoute{Labels: map[string]string{"workloadtype": "deployment"}},
+			labels: map[string]string{"workloadType": "deployment"},
+			want:   true,
+		},
+		{
+			name:   "all labels have to match",
+			route:  config.ConfigRoute{Labels: map[string]string{"team": "a", "stage": "prod"}},
+			labels: WithObjectLabels(map[string]string{}, map[string]string{"team": "a", "stage": "dev"}),
+			want:   false,
+		},
+		{
+			name:   "namespace and labels",
+			route:  config.ConfigRoute{Namespaces: []string{"team-a"}, Labels: map[string]string{"team": "a"}},
+			labels: WithObjectLabels(map[string]string{"namespace": "team-b"}, map[string]string{"team": "a"}),
+			want:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchRoute(test.route, test.labels); got != test.want {
+				t.Errorf("matchRoute() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetRouteRefs(t *testing.T) {
+	cfg := &config.Config{
+		Routes: []config.ConfigRoute{
+			{Namespaces: []string{"team-a"}, APIKeyRef: config.ConfigSecretKeyRef{Name: "team-a"}},
+			{Labels: map[string]string{"team": "b"}, APIKeyRef: config.ConfigSecretKeyRef{Name: "team-b"}},
+			{APIKeyRef: config.ConfigSecretKeyRef{Name: "catch-all"}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		labels       map[string]string
+		namespaceRef string
+		want         []routeRef
+	}{
+		{
+			name:   "first matching route",
+			labels: WithObjectLabels(map[string]string{"namespace": "team-a"}, map[string]string{"team": "b"}),
+			want:   []routeRef{{ref: config.ConfigSecretKeyRef{Name: "team-a"}}},
+		},
+		{
+			name:   "object label route",
+			labels: WithObjectLabels(map[string]string{"namespace": "default"}, map[string]string{"team": "b"}),
+			want:   []routeRef{{ref: config.ConfigSecretKeyRef{Name: "team-b"}}},
+		},
+		{
+			name:         "namespace annotation before routes",
+			labels:       map[string]string{"namespace": "default"},
+			namespaceRef: "custom/token",
+			want: []routeRef{
+				{ref: config.ConfigSecretKeyRef{Name: "custom", Key: "token"}, requireLabel: true},
+				{ref: config.ConfigSecretKeyRef{Name: "catch-all"}},
+			},
+		},
+		{
+			name:         "namespace annotation of a route secret",
+			labels:       map[string]string{"namespace": "default"},
+			namespaceRef: "team-b",
+			want: []routeRef{
+				{ref: config.ConfigSecretKeyRef{Name: "team-b"}},
+				{ref: config.ConfigSecretKeyRef{Name: "catch-all"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getRouteRefs(cfg, test.labels, test.namespaceRef); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("getRouteRefs() = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	t.Run("no matching route", func(t *testing.T) {
+		cfg := &config.Config{Routes: cfg.Routes[:2]}
+		if got := getRouteRefs(cfg, map[string]string{"namespace": "default"}, ""); len(got) != 0 {
+			t.Errorf("getRouteRefs() = %v, want none", got)
+		}
+	})
+}
+
+func TestGetRouteAPIKeysFallback(t *testing.T) {
+	cfg := &config.Config{Settings: config.ConfigSettings{APIKey: "fallback"}}
+	if got := getRouteAPIKeys(cfg, map[string]string{"namespace": "default"}); got != "fallback" {
+		t.Errorf("getRouteAPIKeys() = %v, want fallback", got)
+	}
+}
+
+func TestGetSentLabels(t *testing.T) {
+	labels := WithObjectLabels(map[string]string{"namespace": "default"}, map[string]string{"team": "a"})
+	want := map[string]string{"namespace": "default"}
+	if got := getSentLabels(labels); !reflect.DeepEqual(got, want) {
+		t.Errorf("getSentLabels() = %v, want %v", got, want)
+	}
+}
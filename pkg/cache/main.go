@@ -19,6 +19,7 @@ var Cache cacheInterface
 
 type cacheInterface struct {
 	Events cacheClientInterface
+	State  cacheClientInterface
 }
 
 type cacheClientInterface struct {
@@ -26,7 +27,8 @@ type cacheClientInterface struct {
 	lruClient *ccache.Cache
 }
 
-func (rc *cacheInterface) Init() {
+// Init initializes the Redis cache if enabled and the in memory caches, the state cache keeps stateSize entries at most
+func (rc *cacheInterface) Init(stateSize int64) {
 	if utils.GetEnv("REDIS_ENABLED", "") == "true" {
 		log.Debug().Msg("Initializing Redis cache")
 		redisHost := utils.GetEnv("REDIS_HOST", "localhost")
@@ -37,9 +39,11 @@ func (rc *cacheInterface) Init() {
 			Password: redisPassword,
 			DB:       0,
 		})
+		rc.State.Client = rc.Events.Client
 		log.Debug().Msg("Redis cache initialized")
 	}
 	rc.Events.lruClient = ccache.New(ccache.Configure().MaxSize(5000).ItemsToPrune(500))
+	rc.State.lruClient = ccache.New(ccache.Configure().MaxSize(stateSize).ItemsToPrune(uint32(stateSize/10 + 1)))
 }
 
 // CheckInitialization checks init
@@ -217,3 +221,40 @@ func (rc *cacheClientInterface) IncrementItemBy(key string, val int64, ttl time.
 
 	return err
 }
+
+// IncrementItemWithinWindow increment item by n and returns the new value, the ttl is only applied when the item is created
+func (rc *cacheClientInterface) IncrementItemWithinWindow(key string, val int64, ttl time.Duration) (int64, error) {
+	if !rc.CheckInitialization() {
+		return 0, errors.New(" Cache is not initialized ")
+	}
+
+	if rc.Client == nil {
+		item := rc.lruClient.Get(key)
+		if item != nil && !item.Expired() {
+			if itemValue, ok := item.Value().(int64); ok {
+				rc.lruClient.Replace(key, itemValue+val)
+				return itemValue + val, nil
+			}
+		}
+		rc.lruClient.Set(key, val, ttl)
+		return val, nil
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.TODO(), defaultTimeout)
+	defer cancelFn()
+
+	item, err := rc.Client.IncrBy(ctx, key, val).Result()
+	if err != nil {
+		log.Debug().Err(err).Str("key", key).Msg("Failed to increment cache item")
+		return 0, err
+	}
+	if item == val {
+		err = rc.Client.Expire(ctx, key, ttl).Err()
+		if err != nil {
+			log.Debug().Err(err).Str("key", key).Msg("Failed to set cache item expiration")
+			return item, err
+		}
+	}
+
+	return item, nil
+}
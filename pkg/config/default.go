@@ -4,22 +4,24 @@ package config
 func GetDefaultConfig() *Config {
 	return &Config{
 		Settings: ConfigSettings{
-			ElectionID:    "ilert-kube-agent",
-			Namespace:     "kube-system",
-			Port:          9092,
-			CheckInterval: "15s",
+			ElectionID:     "ilert-kube-agent",
+			Namespace:      "kube-system",
+			Port:           9092,
+			CheckInterval:  "15s",
+			StateCacheSize: 5000,
 			Log: ConfigSettingsLog{
 				JSON:  false,
 				Level: "info",
 			},
 		},
 		Alarms: ConfigAlarms{
-			Cluster: ConfigAlarmSetting{
+			Cluster: ConfigAlarmsCluster{
 				Enabled:  true,
 				Priority: "HIGH",
 			},
 			Pods: ConfigAlarmsPods{
-				Enabled: true,
+				Enabled:     true,
+				KeyStrategy: "pod",
 				Terminate: ConfigAlarmSetting{
 					Enabled:  true,
 					Priority: "HIGH",
@@ -28,13 +30,35 @@ func GetDefaultConfig() *Config {
 					Enabled:  true,
 					Priority: "LOW",
 				},
-				Restarts: ConfigAlarmSettingWithThreshold{
+				Restarts: ConfigAlarmSettingRestarts{
 					Enabled:   true,
 					Priority:  "LOW",
 					Threshold: 10,
+					Mode:      "absolute",
+					Window:    "10m",
+				},
+				Pending: ConfigAlarmSettingWithDuration{
+					Enabled:  false,
+					Priority: "LOW",
+					Duration: "5m",
+				},
+				Terminating: ConfigAlarmSettingWithDuration{
+					Enabled:  false,
+					Priority: "LOW",
+					Duration: "10m",
+				},
+				Evictions: ConfigAlarmSetting{
+					Enabled:  false,
+					Priority: "LOW",
+				},
+				OOMKilled: ConfigAlarmSetting{
+					Enabled:  false,
+					Priority: "HIGH",
 				},
 				Resources: ConfigAlarmSettingResources{
-					Enabled: true,
+					Enabled:    true,
+					For:        "0s",
+					Hysteresis: 0,
 					CPU: ConfigAlarmSettingWithThreshold{
 						Enabled:   true,
 						Priority:  "LOW",
@@ -45,6 +69,53 @@ func GetDefaultConfig() *Config {
 						Priority:  "LOW",
 						Threshold: 90,
 					},
+					Prediction: ConfigAlarmSettingPrediction{
+						Enabled:  false,
+						Priority: "LOW",
+						Horizon:  "30m",
+						Samples:  20,
+					},
+					Requests: ConfigAlarmSettingWithThreshold{
+						Enabled:   false,
+						Priority:  "LOW",
+						Threshold: 100,
+					},
+				},
+				InitContainers: ConfigAlarmsContainers{
+					Enabled: false,
+					Terminate: ConfigAlarmSetting{
+						Enabled:  true,
+						Priority: "HIGH",
+					},
+					Waiting: ConfigAlarmSetting{
+						Enabled:  true,
+						Priority: "LOW",
+					},
+					Restarts: ConfigAlarmSettingRestarts{
+						Enabled:   true,
+						Priority:  "LOW",
+						Threshold: 10,
+						Mode:      "absolute",
+						Window:    "10m",
+					},
+				},
+				EphemeralContainers: ConfigAlarmsContainers{
+					Enabled: false,
+					Terminate: ConfigAlarmSetting{
+						Enabled:  true,
+						Priority: "LOW",
+					},
+					Waiting: ConfigAlarmSetting{
+						Enabled:  true,
+						Priority: "LOW",
+					},
+					Restarts: ConfigAlarmSettingRestarts{
+						Enabled:   true,
+						Priority:  "LOW",
+						Threshold: 10,
+						Mode:      "absolute",
+						Window:    "10m",
+					},
 				},
 			},
 			Nodes: ConfigAlarmsNodes{
@@ -54,7 +125,9 @@ func GetDefaultConfig() *Config {
 					Priority: "HIGH",
 				},
 				Resources: ConfigAlarmSettingResources{
-					Enabled: true,
+					Enabled:    true,
+					For:        "0s",
+					Hysteresis: 0,
 					CPU: ConfigAlarmSettingWithThreshold{
 						Enabled:   true,
 						Priority:  "LOW",
@@ -65,8 +138,153 @@ func GetDefaultConfig() *Config {
 						Priority:  "LOW",
 						Threshold: 90,
 					},
+					Prediction: ConfigAlarmSettingPrediction{
+						Enabled:  false,
+						Priority: "LOW",
+						Horizon:  "30m",
+						Samples:  20,
+					},
+				},
+				Conditions: ConfigAlarmsNodeConditions{
+					Enabled:  false,
+					Priority: "HIGH",
+					Duration: "1m",
+					Types:    GetDefaultNodeConditionTypes(),
+				},
+			},
+			Namespaces: ConfigAlarmsNamespaces{
+				Enabled: false,
+				Terminating: ConfigAlarmSettingWithDuration{
+					Enabled:  true,
+					Priority: "LOW",
+					Duration: "30m",
+				},
+			},
+			Events: ConfigAlarmsEvents{
+				Enabled: false,
+				Rules:   GetDefaultEventsRules(),
+			},
+			Jobs: ConfigAlarmsJobs{
+				Enabled: false,
+				Failed: ConfigAlarmSetting{
+					Enabled:  true,
+					Priority: "HIGH",
+				},
+				MissedSchedule: ConfigAlarmSettingWithDuration{
+					Enabled:  true,
+					Priority: "LOW",
+					Duration: "10m",
+				},
+				LongRunning: ConfigAlarmSettingWithDuration{
+					Enabled:  true,
+					Priority: "LOW",
+					Duration: "1h",
+				},
+			},
+			Volumes: ConfigAlarmsVolumes{
+				Enabled:       false,
+				CheckInterval: "5m",
+				Pending: ConfigAlarmSettingWithDuration{
+					Enabled:  true,
+					Priority: "LOW",
+					Duration: "10m",
+				},
+				Lost: ConfigAlarmSetting{
+					Enabled:  true,
+					Priority: "HIGH",
+				},
+				Usage: ConfigAlarmSettingWithThreshold{
+					Enabled:   true,
+					Priority:  "HIGH",
+					Threshold: 90,
+				},
+				Inodes: ConfigAlarmSettingWithThreshold{
+					Enabled:   true,
+					Priority:  "HIGH",
+					Threshold: 90,
+				},
+			},
+			Services: ConfigAlarmsServices{
+				Enabled:  false,
+				Priority: "HIGH",
+				Duration: "5m",
+			},
+			Certificates: ConfigAlarmsCertificates{
+				Enabled:               false,
+				OnlyIngressReferenced: false,
+				CheckInterval:         "1h",
+				Warning: ConfigAlarmSettingWithDuration{
+					Enabled:  true,
+					Priority: "LOW",
+					Duration: "720h",
+				},
+				Critical: ConfigAlarmSettingWithDuration{
+					Enabled:  true,
+					Priority: "HIGH",
+					Duration: "168h",
+				},
+			},
+			Quotas: ConfigAlarmsQuotas{
+				Enabled: false,
+				Usage: ConfigAlarmSettingWithThreshold{
+					Enabled:   true,
+					Priority:  "LOW",
+					Threshold: 90,
+				},
+				LimitRanges: ConfigAlarmSettingWithThreshold{
+					Enabled:   true,
+					Priority:  "LOW",
+					Threshold: 90,
+				},
+			},
+			Autoscalers: ConfigAlarmsAutoscalers{
+				Enabled:  false,
+				Priority: "LOW",
+				Duration: "15m",
+			},
+			Workloads: ConfigAlarmsWorkloads{
+				Enabled: false,
+				Deployments: ConfigAlarmSettingWorkload{
+					Enabled:   true,
+					Priority:  "HIGH",
+					Threshold: 1,
+					Duration:  "10m",
+				},
+				StatefulSets: ConfigAlarmSettingWorkload{
+					Enabled:   true,
+					Priority:  "HIGH",
+					Threshold: 1,
+					Duration:  "10m",
+				},
+				DaemonSets: ConfigAlarmSettingWorkload{
+					Enabled:   true,
+					Priority:  "LOW",
+					Threshold: 1,
+					Duration:  "10m",
 				},
 			},
 		},
 	}
 }
+
+// GetDefaultNodeConditionTypes returns default node condition settings
+func GetDefaultNodeConditionTypes() []ConfigAlarmSettingCondition {
+	return []ConfigAlarmSettingCondition{
+		{Type: "Ready", Priority: "HIGH", Duration: "1m"},
+		{Type: "MemoryPressure", Priority: "LOW", Duration: "5m"},
+		{Type: "DiskPressure", Priority: "LOW", Duration: "5m"},
+		{Type: "PIDPressure", Priority: "LOW", Duration: "5m"},
+		{Type: "NetworkUnavailable", Priority: "HIGH", Duration: "1m"},
+	}
+}
+
+// GetDefaultEventsRules returns default warning events rules
+func GetDefaultEventsRules() []ConfigAlarmsEventsRule {
+	return []ConfigAlarmsEventsRule{
+		{Reasons: []string{"FailedMount", "FailedAttachVolume"}, Kinds: []string{"Pod"}, Priority: "HIGH", Threshold: 3, Window: "10m"},
+		{Reasons: []string{"FailedCreatePodSandBox"}, Kinds: []string{"Pod"}, Priority: "LOW", Threshold: 3, Window: "10m"},
+		{Reasons: []string{"Unhealthy"}, Kinds: []string{"Pod"}, Priority: "LOW", Threshold: 10, Window: "10m"},
+		{Reasons: []string{"BackOff"}, Kinds: []string{"Pod"}, Priority: "LOW", Threshold: 10, Window: "10m"},
+		{Reasons: []string{"NodeNotReady"}, Priority: "HIGH", Threshold: 1, Window: "5m"},
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/rs/zerolog/log"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -44,6 +45,15 @@ func (cfg *Config) initializeClients() {
 			cfg.MetricsClient = metricsClient
 		}
 	}
+
+	if cfg.DynamicClient == nil {
+		dynamicClient, err := dynamic.NewForConfig(cfg.KubeConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create dynamic client")
+		} else {
+			cfg.DynamicClient = dynamicClient
+		}
+	}
 }
 
 func (cfg *Config) Print() {
@@ -59,16 +69,21 @@ func (cfg *Config) Print() {
 		Log:                  cfg.Settings.Log,
 		ElectionID:           cfg.Settings.ElectionID,
 		CheckInterval:        cfg.Settings.CheckInterval,
+		StateCacheSize:       cfg.Settings.StateCacheSize,
+		ExternalURL:          cfg.Settings.ExternalURL,
+		Scope:                cfg.Settings.Scope,
 	}
 
 	log.Info().Interface("config", struct {
 		Settings ConfigSettings
 		Alarms   ConfigAlarms
 		Links    ConfigLinks
+		Routes   []ConfigRoute
 	}{
 		Settings: sanitizedSettings,
 		Alarms:   cfg.Alarms,
 		Links:    cfg.Links,
+		Routes:   cfg.Routes,
 	}).Msg("Starting with config")
 }
 
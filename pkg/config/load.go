@@ -50,6 +50,15 @@ func (cfg *Config) Load() {
 	if cfg.Links.Nodes == nil {
 		cfg.Links.Nodes = make([]ConfigLinksSetting, 0)
 	}
+	if cfg.Links.Workloads == nil {
+		cfg.Links.Workloads = make([]ConfigLinksSetting, 0)
+	}
+	if cfg.Alarms.Nodes.Conditions.Types == nil {
+		cfg.Alarms.Nodes.Conditions.Types = GetDefaultNodeConditionTypes()
+	}
+	if cfg.Alarms.Events.Rules == nil {
+		cfg.Alarms.Events.Rules = GetDefaultEventsRules()
+	}
 
 	for _, e := range os.Environ() {
 		pair := strings.SplitN(e, "=", 2)
@@ -67,6 +76,13 @@ func (cfg *Config) Load() {
 				Href: pair[1],
 			})
 		}
+
+		if strings.HasPrefix(pair[0], "ILERT_LINKS_WORKLOADS_") {
+			cfg.Links.Workloads = append(cfg.Links.Workloads, ConfigLinksSetting{
+				Name: strings.Title(strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(pair[0], "ILERT_LINKS_WORKLOADS_", ""), "_", " "))),
+				Href: pair[1],
+			})
+		}
 	}
 
 	ilertAPIKeyEnv := utils.GetEnv("ILERT_API_KEY", "")
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -11,24 +12,37 @@ type Config struct {
 	KubeConfig    *rest.Config
 	KubeClient    *kubernetes.Clientset
 	MetricsClient *metrics.Clientset
+	DynamicClient *dynamic.DynamicClient
 
 	Settings ConfigSettings `yaml:"settings" json:"settings"`
 	Alarms   ConfigAlarms   `yaml:"alarms" json:"alarms"`
 	Links    ConfigLinks    `yaml:"links" json:"links"`
+	Routes   []ConfigRoute  `yaml:"routes" json:"routes"`
 }
 
 // ConfigSettings definition
 type ConfigSettings struct {
-	APIKey               string            `yaml:"apiKey" json:"apiKey"`
-	HttpAuthorizationKey string            `yaml:"httpAuthorizationKey" json:"httpAuthorizationKey"`
-	KubeConfig           string            `yaml:"kubeconfig" json:"kubeconfig"`
-	Master               string            `yaml:"master" json:"master"`
-	Insecure             bool              `yaml:"insecure" json:"insecure"`
-	Namespace            string            `yaml:"namespace" json:"namespace"`
-	Port                 int               `yaml:"port" json:"port"`
-	Log                  ConfigSettingsLog `yaml:"log" json:"log"`
-	ElectionID           string            `yaml:"electionID" json:"electionID"`
-	CheckInterval        string            `yaml:"checkInterval" json:"checkInterval"`
+	APIKey               string              `yaml:"apiKey" json:"apiKey"`
+	HttpAuthorizationKey string              `yaml:"httpAuthorizationKey" json:"httpAuthorizationKey"`
+	KubeConfig           string              `yaml:"kubeconfig" json:"kubeconfig"`
+	Master               string              `yaml:"master" json:"master"`
+	Insecure             bool                `yaml:"insecure" json:"insecure"`
+	Namespace            string              `yaml:"namespace" json:"namespace"`
+	Port                 int                 `yaml:"port" json:"port"`
+	Log                  ConfigSettingsLog   `yaml:"log" json:"log"`
+	ElectionID           string              `yaml:"electionID" json:"electionID"`
+	CheckInterval        string              `yaml:"checkInterval" json:"checkInterval"`
+	StateCacheSize       int64               `yaml:"stateCacheSize" json:"stateCacheSize"`
+	ExternalURL          string              `yaml:"externalURL" json:"externalURL"`
+	Scope                ConfigSettingsScope `yaml:"scope" json:"scope"`
+}
+
+// ConfigSettingsScope definition, namespaces support glob patterns e.g. team-*
+type ConfigSettingsScope struct {
+	IncludeNamespaces []string `yaml:"includeNamespaces" json:"includeNamespaces"`
+	ExcludeNamespaces []string `yaml:"excludeNamespaces" json:"excludeNamespaces"`
+	LabelSelector     string   `yaml:"labelSelector" json:"labelSelector"`
+	FieldSelector     string   `yaml:"fieldSelector" json:"fieldSelector"`
 }
 
 // ConfigSettingsLog definition
@@ -39,19 +53,52 @@ type ConfigSettingsLog struct {
 
 // ConfigAlarms definition
 type ConfigAlarms struct {
-	Cluster ConfigAlarmSetting `yaml:"cluster" json:"cluster"`
-	Pods    ConfigAlarmsPods   `yaml:"pods" json:"pods"`
-	Nodes   ConfigAlarmsNodes  `yaml:"nodes" json:"nodes"`
+	Cluster         ConfigAlarmsCluster          `yaml:"cluster" json:"cluster"`
+	Pods            ConfigAlarmsPods             `yaml:"pods" json:"pods"`
+	Nodes           ConfigAlarmsNodes            `yaml:"nodes" json:"nodes"`
+	Namespaces      ConfigAlarmsNamespaces       `yaml:"namespaces" json:"namespaces"`
+	Events          ConfigAlarmsEvents           `yaml:"events" json:"events"`
+	Jobs            ConfigAlarmsJobs             `yaml:"jobs" json:"jobs"`
+	Workloads       ConfigAlarmsWorkloads        `yaml:"workloads" json:"workloads"`
+	Autoscalers     ConfigAlarmsAutoscalers      `yaml:"autoscalers" json:"autoscalers"`
+	Volumes         ConfigAlarmsVolumes          `yaml:"volumes" json:"volumes"`
+	Services        ConfigAlarmsServices         `yaml:"services" json:"services"`
+	Certificates    ConfigAlarmsCertificates     `yaml:"certificates" json:"certificates"`
+	Quotas          ConfigAlarmsQuotas           `yaml:"quotas" json:"quotas"`
+	CustomResources []ConfigAlarmsCustomResource `yaml:"customResources" json:"customResources"`
+}
+
+// ConfigAlarmsCluster definition
+type ConfigAlarmsCluster struct {
+	Enabled           bool     `yaml:"enabled" json:"enabled"`
+	SendResolveEvents bool     `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	Priority          string   `yaml:"priority" json:"priority"`
+	ExcludedChecks    []string `yaml:"excludedChecks" json:"excludedChecks"`
 }
 
 // ConfigAlarmsPods definition
 type ConfigAlarmsPods struct {
-	Enabled           bool                            `yaml:"enabled" json:"enabled"`
-	SendResolveEvents bool                            `yaml:"sendResolveEvents" json:"sendResolveEvents"`
-	Terminate         ConfigAlarmSetting              `yaml:"terminate" json:"terminate"`
-	Waiting           ConfigAlarmSetting              `yaml:"waiting" json:"waiting"`
-	Restarts          ConfigAlarmSettingWithThreshold `yaml:"restarts" json:"restarts"`
-	Resources         ConfigAlarmSettingResources     `yaml:"resources" json:"resources"`
+	Enabled             bool                           `yaml:"enabled" json:"enabled"`
+	SendResolveEvents   bool                           `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	KeyStrategy         string                         `yaml:"keyStrategy" json:"keyStrategy"`
+	Terminate           ConfigAlarmSetting             `yaml:"terminate" json:"terminate"`
+	Waiting             ConfigAlarmSetting             `yaml:"waiting" json:"waiting"`
+	Restarts            ConfigAlarmSettingRestarts     `yaml:"restarts" json:"restarts"`
+	Pending             ConfigAlarmSettingWithDuration `yaml:"pending" json:"pending"`
+	Terminating         ConfigAlarmSettingWithDuration `yaml:"terminating" json:"terminating"`
+	Evictions           ConfigAlarmSetting             `yaml:"evictions" json:"evictions"`
+	OOMKilled           ConfigAlarmSetting             `yaml:"oomKilled" json:"oomKilled"`
+	Resources           ConfigAlarmSettingResources    `yaml:"resources" json:"resources"`
+	InitContainers      ConfigAlarmsContainers         `yaml:"initContainers" json:"initContainers"`
+	EphemeralContainers ConfigAlarmsContainers         `yaml:"ephemeralContainers" json:"ephemeralContainers"`
+}
+
+// ConfigAlarmsContainers definition
+type ConfigAlarmsContainers struct {
+	Enabled   bool                       `yaml:"enabled" json:"enabled"`
+	Terminate ConfigAlarmSetting         `yaml:"terminate" json:"terminate"`
+	Waiting   ConfigAlarmSetting         `yaml:"waiting" json:"waiting"`
+	Restarts  ConfigAlarmSettingRestarts `yaml:"restarts" json:"restarts"`
 }
 
 // ConfigAlarmsNodes definition
@@ -60,6 +107,122 @@ type ConfigAlarmsNodes struct {
 	SendResolveEvents bool                        `yaml:"sendResolveEvents" json:"sendResolveEvents"`
 	Terminate         ConfigAlarmSetting          `yaml:"terminate" json:"terminate"`
 	Resources         ConfigAlarmSettingResources `yaml:"resources" json:"resources"`
+	Conditions        ConfigAlarmsNodeConditions  `yaml:"conditions" json:"conditions"`
+}
+
+// ConfigAlarmsNamespaces definition
+type ConfigAlarmsNamespaces struct {
+	Enabled           bool                           `yaml:"enabled" json:"enabled"`
+	SendResolveEvents bool                           `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	Terminating       ConfigAlarmSettingWithDuration `yaml:"terminating" json:"terminating"`
+}
+
+// ConfigAlarmsNodeConditions definition
+type ConfigAlarmsNodeConditions struct {
+	Enabled       bool                          `yaml:"enabled" json:"enabled"`
+	Priority      string                        `yaml:"priority" json:"priority"`
+	Duration      string                        `yaml:"duration" json:"duration"`
+	ExcludedTypes []string                      `yaml:"excludedTypes" json:"excludedTypes"`
+	Types         []ConfigAlarmSettingCondition `yaml:"types" json:"types"`
+}
+
+// ConfigAlarmSettingCondition definition
+type ConfigAlarmSettingCondition struct {
+	Type     string `yaml:"type" json:"type"`
+	Priority string `yaml:"priority" json:"priority"`
+	Duration string `yaml:"duration" json:"duration"`
+}
+
+// ConfigAlarmsJobs definition
+type ConfigAlarmsJobs struct {
+	Enabled           bool                           `yaml:"enabled" json:"enabled"`
+	SendResolveEvents bool                           `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	Failed            ConfigAlarmSetting             `yaml:"failed" json:"failed"`
+	MissedSchedule    ConfigAlarmSettingWithDuration `yaml:"missedSchedule" json:"missedSchedule"`
+	LongRunning       ConfigAlarmSettingWithDuration `yaml:"longRunning" json:"longRunning"`
+}
+
+// ConfigAlarmsWorkloads definition
+type ConfigAlarmsWorkloads struct {
+	Enabled           bool                       `yaml:"enabled" json:"enabled"`
+	SendResolveEvents bool                       `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	Deployments       ConfigAlarmSettingWorkload `yaml:"deployments" json:"deployments"`
+	StatefulSets      ConfigAlarmSettingWorkload `yaml:"statefulSets" json:"statefulSets"`
+	DaemonSets        ConfigAlarmSettingWorkload `yaml:"daemonSets" json:"daemonSets"`
+}
+
+// ConfigAlarmsAutoscalers definition
+type ConfigAlarmsAutoscalers struct {
+	Enabled           bool   `yaml:"enabled" json:"enabled"`
+	SendResolveEvents bool   `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	Priority          string `yaml:"priority" json:"priority"`
+	Duration          string `yaml:"duration" json:"duration"`
+}
+
+// ConfigAlarmsVolumes definition
+type ConfigAlarmsVolumes struct {
+	Enabled           bool                            `yaml:"enabled" json:"enabled"`
+	SendResolveEvents bool                            `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	Pending           ConfigAlarmSettingWithDuration  `yaml:"pending" json:"pending"`
+	Lost              ConfigAlarmSetting              `yaml:"lost" json:"lost"`
+	Usage             ConfigAlarmSettingWithThreshold `yaml:"usage" json:"usage"`
+	Inodes            ConfigAlarmSettingWithThreshold `yaml:"inodes" json:"inodes"`
+	CheckInterval     string                          `yaml:"checkInterval" json:"checkInterval"`
+}
+
+// ConfigAlarmsServices definition
+type ConfigAlarmsServices struct {
+	Enabled           bool     `yaml:"enabled" json:"enabled"`
+	SendResolveEvents bool     `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	Priority          string   `yaml:"priority" json:"priority"`
+	Duration          string   `yaml:"duration" json:"duration"`
+	Namespaces        []string `yaml:"namespaces" json:"namespaces"`
+	LabelSelector     string   `yaml:"labelSelector" json:"labelSelector"`
+}
+
+// ConfigAlarmsCertificates definition
+type ConfigAlarmsCertificates struct {
+	Enabled               bool                           `yaml:"enabled" json:"enabled"`
+	SendResolveEvents     bool                           `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	OnlyIngressReferenced bool                           `yaml:"onlyIngressReferenced" json:"onlyIngressReferenced"`
+	CheckInterval         string                         `yaml:"checkInterval" json:"checkInterval"`
+	Warning               ConfigAlarmSettingWithDuration `yaml:"warning" json:"warning"`
+	Critical              ConfigAlarmSettingWithDuration `yaml:"critical" json:"critical"`
+}
+
+// ConfigAlarmsQuotas definition
+type ConfigAlarmsQuotas struct {
+	Enabled           bool                            `yaml:"enabled" json:"enabled"`
+	SendResolveEvents bool                            `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+	Usage             ConfigAlarmSettingWithThreshold `yaml:"usage" json:"usage"`
+	LimitRanges       ConfigAlarmSettingWithThreshold `yaml:"limitRanges" json:"limitRanges"`
+}
+
+// ConfigAlarmsCustomResource definition
+type ConfigAlarmsCustomResource struct {
+	Group             string `yaml:"group" json:"group"`
+	Version           string `yaml:"version" json:"version"`
+	Resource          string `yaml:"resource" json:"resource"`
+	Condition         string `yaml:"condition" json:"condition"`
+	Status            string `yaml:"status" json:"status"`
+	Priority          string `yaml:"priority" json:"priority"`
+	Duration          string `yaml:"duration" json:"duration"`
+	SendResolveEvents bool   `yaml:"sendResolveEvents" json:"sendResolveEvents"`
+}
+
+// ConfigAlarmsEvents definition
+type ConfigAlarmsEvents struct {
+	Enabled bool                     `yaml:"enabled" json:"enabled"`
+	Rules   []ConfigAlarmsEventsRule `yaml:"rules" json:"rules"`
+}
+
+// ConfigAlarmsEventsRule definition
+type ConfigAlarmsEventsRule struct {
+	Reasons   []string `yaml:"reasons" json:"reasons"`
+	Kinds     []string `yaml:"kinds" json:"kinds"`
+	Priority  string   `yaml:"priority" json:"priority"`
+	Threshold int32    `yaml:"threshold" json:"threshold"`
+	Window    string   `yaml:"window" json:"window"`
 }
 
 // ConfigAlarmSetting definition
@@ -76,17 +239,68 @@ type ConfigAlarmSettingWithThreshold struct {
 	Threshold int32  `yaml:"threshold" json:"threshold"`
 }
 
+// ConfigAlarmSettingRestarts definition, mode is either absolute (lifetime restart count) or rate (restarts within window)
+type ConfigAlarmSettingRestarts struct {
+	Enabled   bool   `yaml:"enabled" json:"enabled"`
+	Priority  string `yaml:"priority" json:"priority"`
+	Threshold int32  `yaml:"threshold" json:"threshold"`
+	Mode      string `yaml:"mode" json:"mode"`
+	Window    string `yaml:"window" json:"window"`
+}
+
+// ConfigAlarmSettingWithDuration definition
+type ConfigAlarmSettingWithDuration struct {
+	Enabled  bool   `yaml:"enabled" json:"enabled"`
+	Priority string `yaml:"priority" json:"priority"`
+	Duration string `yaml:"duration" json:"duration"`
+}
+
+// ConfigAlarmSettingWorkload definition
+type ConfigAlarmSettingWorkload struct {
+	Enabled   bool   `yaml:"enabled" json:"enabled"`
+	Priority  string `yaml:"priority" json:"priority"`
+	Threshold int32  `yaml:"threshold" json:"threshold"`
+	Duration  string `yaml:"duration" json:"duration"`
+}
+
 // ConfigAlarmSettingResources definition
 type ConfigAlarmSettingResources struct {
-	Enabled bool                            `yaml:"enabled" json:"enabled"`
-	CPU     ConfigAlarmSettingWithThreshold `yaml:"cpu" json:"cpu"`
-	Memory  ConfigAlarmSettingWithThreshold `yaml:"memory" json:"memory"`
+	Enabled    bool                            `yaml:"enabled" json:"enabled"`
+	For        string                          `yaml:"for" json:"for"`
+	Hysteresis int32                           `yaml:"hysteresis" json:"hysteresis"`
+	CPU        ConfigAlarmSettingWithThreshold `yaml:"cpu" json:"cpu"`
+	Memory     ConfigAlarmSettingWithThreshold `yaml:"memory" json:"memory"`
+	// Requests is only evaluated for pods
+	Requests   ConfigAlarmSettingWithThreshold `yaml:"requests" json:"requests"`
+	Prediction ConfigAlarmSettingPrediction    `yaml:"prediction" json:"prediction"`
+}
+
+// ConfigAlarmSettingPrediction definition, alarms if the memory usage trend of the last samples reaches the limit within horizon
+type ConfigAlarmSettingPrediction struct {
+	Enabled  bool   `yaml:"enabled" json:"enabled"`
+	Priority string `yaml:"priority" json:"priority"`
+	Horizon  string `yaml:"horizon" json:"horizon"`
+	Samples  int32  `yaml:"samples" json:"samples"`
 }
 
 // ConfigLinks definition
 type ConfigLinks struct {
-	Pods  []ConfigLinksSetting `yaml:"pods" json:"pods"`
-	Nodes []ConfigLinksSetting `yaml:"nodes" json:"nodes"`
+	Pods      []ConfigLinksSetting `yaml:"pods" json:"pods"`
+	Nodes     []ConfigLinksSetting `yaml:"nodes" json:"nodes"`
+	Workloads []ConfigLinksSetting `yaml:"workloads" json:"workloads"`
+}
+
+// ConfigRoute definition, routes the alerts of matching namespaces and labels to the api key of the referenced secret
+type ConfigRoute struct {
+	Namespaces []string           `yaml:"namespaces" json:"namespaces"`
+	Labels     map[string]string  `yaml:"labels" json:"labels"`
+	APIKeyRef  ConfigSecretKeyRef `yaml:"apiKeyRef" json:"apiKeyRef"`
+}
+
+// ConfigSecretKeyRef definition, the secret has to be in the agent namespace
+type ConfigSecretKeyRef struct {
+	Name string `yaml:"name" json:"name"`
+	Key  string `yaml:"key" json:"key"`
 }
 
 // ConfigLinksSetting definition
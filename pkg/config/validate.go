@@ -2,8 +2,12 @@ package config
 
 import (
 	"fmt"
+	"path"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // Validate analyze config values and throws an error if some problem found
@@ -24,20 +28,151 @@ func (cfg *Config) Validate() {
 		log.Fatal().Msg("Invalid --settings.log.level flag value or config.")
 	}
 
+	if cfg.Settings.StateCacheSize < 1 {
+		log.Fatal().Msg("Invalid --settings.stateCacheSize flag value.")
+	}
+
+	checkLabelSelector(cfg.Settings.Scope.LabelSelector, "--settings.scope.labelSelector")
+	checkFieldSelector(cfg.Settings.Scope.FieldSelector, "--settings.scope.fieldSelector")
+	for i, namespace := range cfg.Settings.Scope.IncludeNamespaces {
+		checkNamespacePattern(namespace, fmt.Sprintf("settings.scope.includeNamespaces[%d]", i))
+	}
+	for i, namespace := range cfg.Settings.Scope.ExcludeNamespaces {
+		checkNamespacePattern(namespace, fmt.Sprintf("settings.scope.excludeNamespaces[%d]", i))
+	}
+
+	checkKeyStrategy(cfg.Alarms.Pods.KeyStrategy, "--alarms.pods.keyStrategy")
 	checkPriority(cfg.Alarms.Pods.Terminate.Priority, "--alarms.pods.terminate.priority")
 	checkPriority(cfg.Alarms.Pods.Waiting.Priority, "--alarms.pods.waiting.priority")
 	checkPriority(cfg.Alarms.Pods.Restarts.Priority, "--alarms.pods.restarts.priority")
+	checkPriority(cfg.Alarms.Pods.Pending.Priority, "--alarms.pods.pending.priority")
+	checkPriority(cfg.Alarms.Pods.Terminating.Priority, "--alarms.pods.terminating.priority")
+	checkPriority(cfg.Alarms.Pods.Evictions.Priority, "--alarms.pods.evictions.priority")
+	checkPriority(cfg.Alarms.Pods.OOMKilled.Priority, "--alarms.pods.oomKilled.priority")
 	checkPriority(cfg.Alarms.Pods.Resources.CPU.Priority, "--alarms.pods.resources.cpu.priority")
 	checkPriority(cfg.Alarms.Pods.Resources.Memory.Priority, "--alarms.pods.resources.memory.priority")
+	checkPriority(cfg.Alarms.Pods.Resources.Requests.Priority, "--alarms.pods.resources.requests.priority")
+	checkPriority(cfg.Alarms.Pods.InitContainers.Terminate.Priority, "--alarms.pods.initContainers.terminate.priority")
+	checkPriority(cfg.Alarms.Pods.InitContainers.Waiting.Priority, "--alarms.pods.initContainers.waiting.priority")
+	checkPriority(cfg.Alarms.Pods.InitContainers.Restarts.Priority, "--alarms.pods.initContainers.restarts.priority")
+	checkPriority(cfg.Alarms.Pods.EphemeralContainers.Terminate.Priority, "--alarms.pods.ephemeralContainers.terminate.priority")
+	checkPriority(cfg.Alarms.Pods.EphemeralContainers.Waiting.Priority, "--alarms.pods.ephemeralContainers.waiting.priority")
+	checkPriority(cfg.Alarms.Pods.EphemeralContainers.Restarts.Priority, "--alarms.pods.ephemeralContainers.restarts.priority")
 	checkPriority(cfg.Alarms.Nodes.Terminate.Priority, "--alarms.nodes.terminate.priority")
 	checkPriority(cfg.Alarms.Nodes.Resources.CPU.Priority, "--alarms.nodes.resources.cpu.priority")
 	checkPriority(cfg.Alarms.Nodes.Resources.Memory.Priority, "--alarms.nodes.resources.memory.priority")
+	checkPriority(cfg.Alarms.Nodes.Conditions.Priority, "--alarms.nodes.conditions.priority")
+	checkPriority(cfg.Alarms.Jobs.Failed.Priority, "--alarms.jobs.failed.priority")
+	checkPriority(cfg.Alarms.Jobs.MissedSchedule.Priority, "--alarms.jobs.missedSchedule.priority")
+	checkPriority(cfg.Alarms.Jobs.LongRunning.Priority, "--alarms.jobs.longRunning.priority")
+	checkPriority(cfg.Alarms.Workloads.Deployments.Priority, "--alarms.workloads.deployments.priority")
+	checkPriority(cfg.Alarms.Workloads.StatefulSets.Priority, "--alarms.workloads.statefulSets.priority")
+	checkPriority(cfg.Alarms.Workloads.DaemonSets.Priority, "--alarms.workloads.daemonSets.priority")
+	checkPriority(cfg.Alarms.Namespaces.Terminating.Priority, "--alarms.namespaces.terminating.priority")
+	checkPriority(cfg.Alarms.Autoscalers.Priority, "--alarms.autoscalers.priority")
+	checkPriority(cfg.Alarms.Volumes.Pending.Priority, "--alarms.volumes.pending.priority")
+	checkPriority(cfg.Alarms.Volumes.Lost.Priority, "--alarms.volumes.lost.priority")
+	checkPriority(cfg.Alarms.Volumes.Usage.Priority, "--alarms.volumes.usage.priority")
+	checkPriority(cfg.Alarms.Volumes.Inodes.Priority, "--alarms.volumes.inodes.priority")
+	checkPriority(cfg.Alarms.Services.Priority, "--alarms.services.priority")
+	checkPriority(cfg.Alarms.Certificates.Warning.Priority, "--alarms.certificates.warning.priority")
+	checkPriority(cfg.Alarms.Certificates.Critical.Priority, "--alarms.certificates.critical.priority")
+	checkPriority(cfg.Alarms.Quotas.Usage.Priority, "--alarms.quotas.usage.priority")
+	checkPriority(cfg.Alarms.Quotas.LimitRanges.Priority, "--alarms.quotas.limitRanges.priority")
 
 	checkThreshold(cfg.Alarms.Pods.Resources.CPU.Threshold, 1, 100, "--alarms.pods.resources.cpu.threshold")
 	checkThreshold(cfg.Alarms.Pods.Resources.Memory.Threshold, 1, 100, "--alarms.pods.resources.memory.threshold")
+	checkThreshold(cfg.Alarms.Pods.Resources.Requests.Threshold, 1, 1000, "--alarms.pods.resources.requests.threshold")
+	checkThreshold(cfg.Alarms.Pods.Resources.Hysteresis, 0, 100, "--alarms.pods.resources.hysteresis")
+	checkDuration(cfg.Alarms.Pods.Resources.For, "--alarms.pods.resources.for")
+	checkPriority(cfg.Alarms.Pods.Resources.Prediction.Priority, "--alarms.pods.resources.prediction.priority")
+	checkDuration(cfg.Alarms.Pods.Resources.Prediction.Horizon, "--alarms.pods.resources.prediction.horizon")
+	checkThreshold(cfg.Alarms.Pods.Resources.Prediction.Samples, 3, 1000, "--alarms.pods.resources.prediction.samples")
 	checkThreshold(cfg.Alarms.Pods.Restarts.Threshold, 1, 1000000, "--alarms.pods.restarts.threshold")
+	checkThreshold(cfg.Alarms.Pods.InitContainers.Restarts.Threshold, 1, 1000000, "--alarms.pods.initContainers.restarts.threshold")
+	checkThreshold(cfg.Alarms.Pods.EphemeralContainers.Restarts.Threshold, 1, 1000000, "--alarms.pods.ephemeralContainers.restarts.threshold")
+	checkRestartsMode(cfg.Alarms.Pods.Restarts.Mode, "--alarms.pods.restarts.mode")
+	checkRestartsMode(cfg.Alarms.Pods.InitContainers.Restarts.Mode, "--alarms.pods.initContainers.restarts.mode")
+	checkRestartsMode(cfg.Alarms.Pods.EphemeralContainers.Restarts.Mode, "--alarms.pods.ephemeralContainers.restarts.mode")
+	checkDuration(cfg.Alarms.Pods.Restarts.Window, "--alarms.pods.restarts.window")
+	checkDuration(cfg.Alarms.Pods.InitContainers.Restarts.Window, "--alarms.pods.initContainers.restarts.window")
+	checkDuration(cfg.Alarms.Pods.EphemeralContainers.Restarts.Window, "--alarms.pods.ephemeralContainers.restarts.window")
 	checkThreshold(cfg.Alarms.Pods.Resources.CPU.Threshold, 1, 100, "--alarms.nodes.resources.cpu.threshold")
 	checkThreshold(cfg.Alarms.Pods.Resources.Memory.Threshold, 1, 100, "--alarms.nodes.resources.memory.threshold")
+	checkThreshold(cfg.Alarms.Nodes.Resources.Hysteresis, 0, 100, "--alarms.nodes.resources.hysteresis")
+	checkDuration(cfg.Alarms.Nodes.Resources.For, "--alarms.nodes.resources.for")
+	checkPriority(cfg.Alarms.Nodes.Resources.Prediction.Priority, "--alarms.nodes.resources.prediction.priority")
+	checkDuration(cfg.Alarms.Nodes.Resources.Prediction.Horizon, "--alarms.nodes.resources.prediction.horizon")
+	checkThreshold(cfg.Alarms.Nodes.Resources.Prediction.Samples, 3, 1000, "--alarms.nodes.resources.prediction.samples")
+	checkThreshold(cfg.Alarms.Workloads.Deployments.Threshold, 1, 1000000, "--alarms.workloads.deployments.threshold")
+	checkThreshold(cfg.Alarms.Workloads.StatefulSets.Threshold, 1, 1000000, "--alarms.workloads.statefulSets.threshold")
+	checkThreshold(cfg.Alarms.Workloads.DaemonSets.Threshold, 1, 1000000, "--alarms.workloads.daemonSets.threshold")
+	checkThreshold(cfg.Alarms.Volumes.Usage.Threshold, 1, 100, "--alarms.volumes.usage.threshold")
+	checkThreshold(cfg.Alarms.Volumes.Inodes.Threshold, 1, 100, "--alarms.volumes.inodes.threshold")
+	checkThreshold(cfg.Alarms.Quotas.Usage.Threshold, 1, 100, "--alarms.quotas.usage.threshold")
+	checkThreshold(cfg.Alarms.Quotas.LimitRanges.Threshold, 1, 100, "--alarms.quotas.limitRanges.threshold")
+
+	checkDuration(cfg.Alarms.Pods.Pending.Duration, "--alarms.pods.pending.duration")
+	checkDuration(cfg.Alarms.Pods.Terminating.Duration, "--alarms.pods.terminating.duration")
+	checkDuration(cfg.Alarms.Namespaces.Terminating.Duration, "--alarms.namespaces.terminating.duration")
+	checkDuration(cfg.Alarms.Nodes.Conditions.Duration, "--alarms.nodes.conditions.duration")
+	checkDuration(cfg.Alarms.Jobs.MissedSchedule.Duration, "--alarms.jobs.missedSchedule.duration")
+	checkDuration(cfg.Alarms.Jobs.LongRunning.Duration, "--alarms.jobs.longRunning.duration")
+	checkDuration(cfg.Alarms.Workloads.Deployments.Duration, "--alarms.workloads.deployments.duration")
+	checkDuration(cfg.Alarms.Workloads.StatefulSets.Duration, "--alarms.workloads.statefulSets.duration")
+	checkDuration(cfg.Alarms.Workloads.DaemonSets.Duration, "--alarms.workloads.daemonSets.duration")
+	checkDuration(cfg.Alarms.Autoscalers.Duration, "--alarms.autoscalers.duration")
+	checkDuration(cfg.Alarms.Volumes.Pending.Duration, "--alarms.volumes.pending.duration")
+	checkDuration(cfg.Alarms.Services.Duration, "--alarms.services.duration")
+	checkDuration(cfg.Alarms.Volumes.CheckInterval, "--alarms.volumes.checkInterval")
+	checkDuration(cfg.Alarms.Certificates.CheckInterval, "--alarms.certificates.checkInterval")
+	checkDuration(cfg.Alarms.Certificates.Warning.Duration, "--alarms.certificates.warning.duration")
+	checkDuration(cfg.Alarms.Certificates.Critical.Duration, "--alarms.certificates.critical.duration")
+	checkLabelSelector(cfg.Alarms.Services.LabelSelector, "--alarms.services.labelSelector")
+
+	for i, resource := range cfg.Alarms.CustomResources {
+		if resource.Version == "" || resource.Resource == "" {
+			log.Fatal().Msg(fmt.Sprintf("Invalid alarms.customResources[%d] config value, version and resource are required.", i))
+		}
+		if resource.Condition == "" {
+			log.Fatal().Msg(fmt.Sprintf("Invalid alarms.customResources[%d].condition config value.", i))
+		}
+		if resource.Status != "True" && resource.Status != "False" && resource.Status != "Unknown" {
+			log.Fatal().Msg(fmt.Sprintf("Invalid alarms.customResources[%d].status config value (True, False or Unknown).", i))
+		}
+		checkPriority(resource.Priority, fmt.Sprintf("alarms.customResources[%d].priority", i))
+		checkDuration(resource.Duration, fmt.Sprintf("alarms.customResources[%d].duration", i))
+	}
+
+	for i, route := range cfg.Routes {
+		if route.APIKeyRef.Name == "" {
+			log.Fatal().Msg(fmt.Sprintf("Invalid routes[%d].apiKeyRef.name config value, the secret name is required.", i))
+		}
+		for j, namespace := range route.Namespaces {
+			checkNamespacePattern(namespace, fmt.Sprintf("routes[%d].namespaces[%d]", i, j))
+		}
+	}
+
+	for i, condition := range cfg.Alarms.Nodes.Conditions.Types {
+		if condition.Type == "" {
+			log.Fatal().Msg(fmt.Sprintf("Invalid alarms.nodes.conditions.types[%d].type config value.", i))
+		}
+		if condition.Priority != "" {
+			checkPriority(condition.Priority, fmt.Sprintf("alarms.nodes.conditions.types[%d].priority", i))
+		}
+		if condition.Duration != "" {
+			checkDuration(condition.Duration, fmt.Sprintf("alarms.nodes.conditions.types[%d].duration", i))
+		}
+	}
+
+	for i, rule := range cfg.Alarms.Events.Rules {
+		if len(rule.Reasons) == 0 {
+			log.Fatal().Msg(fmt.Sprintf("Invalid alarms.events.rules[%d].reasons config value.", i))
+		}
+		checkPriority(rule.Priority, fmt.Sprintf("alarms.events.rules[%d].priority", i))
+		checkThreshold(rule.Threshold, 1, 1000000, fmt.Sprintf("alarms.events.rules[%d].threshold", i))
+		checkDuration(rule.Window, fmt.Sprintf("alarms.events.rules[%d].window", i))
+	}
 }
 
 func checkPriority(priority string, flag string) {
@@ -46,8 +181,45 @@ func checkPriority(priority string, flag string) {
 	}
 }
 
+func checkRestartsMode(mode string, flag string) {
+	if mode != "absolute" && mode != "rate" {
+		log.Fatal().Msg(fmt.Sprintf("Invalid %s flag value.", flag))
+	}
+}
+
+func checkKeyStrategy(strategy string, flag string) {
+	if strategy != "pod" && strategy != "container" && strategy != "workload" && strategy != "workloadReason" {
+		log.Fatal().Msg(fmt.Sprintf("Invalid %s flag value.", flag))
+	}
+}
+
+func checkLabelSelector(selector string, flag string) {
+	if _, err := labels.Parse(selector); err != nil {
+		log.Fatal().Err(err).Msg(fmt.Sprintf("Invalid %s flag value.", flag))
+	}
+}
+
+func checkFieldSelector(selector string, flag string) {
+	if _, err := fields.ParseSelector(selector); err != nil {
+		log.Fatal().Err(err).Msg(fmt.Sprintf("Invalid %s flag value.", flag))
+	}
+}
+
+func checkNamespacePattern(pattern string, flag string) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		log.Fatal().Err(err).Msg(fmt.Sprintf("Invalid %s config value.", flag))
+	}
+}
+
 func checkThreshold(threshold int32, min int32, max int32, flag string) {
 	if threshold < min || threshold > max {
 		log.Fatal().Msg(fmt.Sprintf("Invalid %s flag value (min=%d max=%d).", flag, min, max))
 	}
 }
+
+func checkDuration(duration string, flag string) {
+	d, err := time.ParseDuration(duration)
+	if err != nil || d < 0 {
+		log.Fatal().Msg(fmt.Sprintf("Invalid %s flag value.", flag))
+	}
+}
@@ -0,0 +1,113 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/rs/zerolog/log"
+)
+
+// openAlertsLocks serializes the read modify write of the open alerts of an object, they are updated by the informers
+// and the checkers at the same time
+var openAlertsLocks [64]sync.Mutex
+
+func lockOpenAlerts(objectKey string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(objectKey))
+	lock := &openAlertsLocks[hash.Sum32()%uint32(len(openAlertsLocks))]
+	lock.Lock()
+	return lock
+}
+
+func getOpenAlertsKey(objectKey string) string {
+	return fmt.Sprintf("state:open-alerts:%s", objectKey)
+}
+
+// getOpenAlerts returns the alert keys that are open for the object
+func getOpenAlerts(objectKey string) []string {
+	lock := lockOpenAlerts(objectKey)
+	defer lock.Unlock()
+	return loadOpenAlerts(objectKey)
+}
+
+func loadOpenAlerts(objectKey string) []string {
+	alertKeys := make([]string, 0)
+	value, err := cache.Cache.State.GetItem(getOpenAlertsKey(objectKey))
+	if err == nil && value != "" {
+		json.Unmarshal([]byte(value), &alertKeys)
+	}
+	return alertKeys
+}
+
+func storeOpenAlerts(objectKey string, alertKeys []string) {
+	if len(alertKeys) == 0 {
+		cache.Cache.State.DeleteItem(getOpenAlertsKey(objectKey))
+		return
+	}
+
+	if data, err := json.Marshal(alertKeys); err == nil {
+		cache.Cache.State.SetItem(getOpenAlertsKey(objectKey), string(data), problemStateTTL)
+	}
+}
+
+// addOpenAlert tracks the alert key as open for the object until it is resolved
+func addOpenAlert(objectKey string, alertKey string) {
+	lock := lockOpenAlerts(objectKey)
+	defer lock.Unlock()
+
+	alertKeys := loadOpenAlerts(objectKey)
+	for _, key := range alertKeys {
+		if key == alertKey {
+			return
+		}
+	}
+	storeOpenAlerts(objectKey, append(alertKeys, alertKey))
+}
+
+// removeOpenAlert stops tracking the alert key of the object and reports whether it was open
+func removeOpenAlert(objectKey string, alertKey string) bool {
+	lock := lockOpenAlerts(objectKey)
+	defer lock.Unlock()
+
+	alertKeys := loadOpenAlerts(objectKey)
+	for i, key := range alertKeys {
+		if key == alertKey {
+			storeOpenAlerts(objectKey, append(alertKeys[:i], alertKeys[i+1:]...))
+			return true
+		}
+	}
+	return false
+}
+
+// takeOpenAlerts stops tracking all open alerts of the object and returns them
+func takeOpenAlerts(objectKey string) []string {
+	lock := lockOpenAlerts(objectKey)
+	defer lock.Unlock()
+
+	alertKeys := loadOpenAlerts(objectKey)
+	storeOpenAlerts(objectKey, nil)
+	return alertKeys
+}
+
+// resolveOpenAlerts stops tracking all open alerts of the object and sends a resolve event for each of them if enabled
+func resolveOpenAlerts(cfg *config.Config, objectKey string, summary string, labels map[string]string, sendResolveEvents bool) {
+	alertKeys := takeOpenAlerts(objectKey)
+	if len(alertKeys) == 0 {
+		return
+	}
+
+	if !sendResolveEvents {
+		return
+	}
+
+	for _, alertKey := range alertKeys {
+		log.Debug().Str("object", objectKey).Str("alertKey", alertKey).Msg("Resolving open alert")
+		alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+	}
+}
@@ -0,0 +1,45 @@
+package watcher
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+)
+
+func TestOpenAlertsConcurrentUpdates(t *testing.T) {
+	cache.Cache.Init(1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			addOpenAlert("default/api", fmt.Sprintf("alert-%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(getOpenAlerts("default/api")); got != 50 {
+		t.Fatalf("open alerts = %d, want 50", got)
+	}
+
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if !removeOpenAlert("default/api", fmt.Sprintf("alert-%d", i)) {
+				t.Errorf("alert-%d was not open", i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(takeOpenAlerts("default/api")); got != 25 {
+		t.Fatalf("open alerts = %d, want 25", got)
+	}
+	if got := len(getOpenAlerts("default/api")); got != 0 {
+		t.Fatalf("open alerts after take = %d, want 0", got)
+	}
+}
@@ -0,0 +1,256 @@
+package watcher
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/utils"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// certificatesObjectKey is the object key the secrets with an open certificate alert are tracked on
+const certificatesObjectKey = "certificates"
+
+func getCertificateKey(namespace string, secretName string) string {
+	return fmt.Sprintf("%s/secret/%s-certificate", namespace, secretName)
+}
+
+// getCertificate returns the leaf certificate of the tls secret
+func getCertificate(secret *api.Secret) (*x509.Certificate, error) {
+	block, _ := pem.Decode(secret.Data[api.TLSCertKey])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func getCertificateNames(certificate *x509.Certificate) []string {
+	names := make([]string, 0, len(certificate.DNSNames)+len(certificate.IPAddresses))
+	names = append(names, certificate.DNSNames...)
+	for _, ip := range certificate.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
+// getIngressSecrets returns the ingresses in scope referencing each tls secret by namespace/name key
+func getIngressSecrets(cfg *config.Config) (map[string][]string, error) {
+	ingresses, err := cfg.KubeClient.NetworkingV1().Ingresses(getScopeNamespace(cfg)).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string][]string)
+	for _, ingress := range ingresses.Items {
+		if !isNamespaceInScope(ingress.GetNamespace(), cfg) {
+			continue
+		}
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+			secretKey := fmt.Sprintf("%s/%s", ingress.GetNamespace(), tls.SecretName)
+			secrets[secretKey] = append(secrets[secretKey], ingress.GetName())
+		}
+	}
+	return secrets, nil
+}
+
+func getCertificateDetails(secret *api.Secret, certificate *x509.Certificate, ingresses []string) string {
+	details := fmt.Sprintf("Secret: %s\nNamespace: %s\nSubject: %s\nIssuer: %s\nSANs: %s\nSerial number: %s\nNot before: %s\nNot after: %s",
+		secret.GetName(),
+		secret.GetNamespace(),
+		certificate.Subject.String(),
+		certificate.Issuer.String(),
+		strings.Join(getCertificateNames(certificate), ", "),
+		certificate.SerialNumber.String(),
+		certificate.NotBefore.Format(time.RFC3339),
+		certificate.NotAfter.Format(time.RFC3339))
+
+	if len(ingresses) > 0 {
+		details += fmt.Sprintf("\nIngresses: %s", strings.Join(ingresses, ", "))
+	}
+	return details
+}
+
+func getCertificateCustomDetails(secret *api.Secret, certificate *x509.Certificate, ingresses []string) map[string]interface{} {
+	customDetails := map[string]interface{}{
+		"secret":        secret.GetName(),
+		"subject":       certificate.Subject.String(),
+		"issuer":        certificate.Issuer.String(),
+		"sans":          getCertificateNames(certificate),
+		"serial_number": certificate.SerialNumber.String(),
+		"not_before":    certificate.NotBefore.Format(time.RFC3339),
+		"not_after":     certificate.NotAfter.Format(time.RFC3339),
+	}
+	if len(ingresses) > 0 {
+		customDetails["ingresses"] = ingresses
+	}
+	return customDetails
+}
+
+// getCertificateSetting returns the most severe expiry window the certificate is in, nil if it is valid long enough
+func getCertificateSetting(cfg *config.Config, remaining time.Duration) *config.ConfigAlarmSettingWithDuration {
+	if cfg.Alarms.Certificates.Critical.Enabled && remaining < getDuration(cfg.Alarms.Certificates.Critical.Duration) {
+		return &cfg.Alarms.Certificates.Critical
+	}
+	if cfg.Alarms.Certificates.Warning.Enabled && remaining < getDuration(cfg.Alarms.Certificates.Warning.Duration) {
+		return &cfg.Alarms.Certificates.Warning
+	}
+	return nil
+}
+
+func analyzeCertificate(secret *api.Secret, ingresses []string, cfg *config.Config) {
+	certificate, err := getCertificate(secret)
+	if err != nil {
+		log.Debug().Err(err).Str("secret", secret.GetName()).Str("namespace", secret.GetNamespace()).Msg("Failed to parse tls secret certificate")
+		return
+	}
+
+	alertKey := getCertificateKey(secret.GetNamespace(), secret.GetName())
+	secretName := fmt.Sprintf("%s/%s", secret.GetNamespace(), secret.GetName())
+	labels := map[string]string{
+		"namespace":       secret.GetNamespace(),
+		"secret":          secret.GetName(),
+		"resourceVersion": secret.GetResourceVersion(),
+	}
+
+	remaining := time.Until(certificate.NotAfter)
+	setting := getCertificateSetting(cfg, remaining)
+	if setting == nil {
+		removeOpenAlert(certificatesObjectKey, secretName)
+		if clearProblemSince(alertKey) && cfg.Alarms.Certificates.SendResolveEvents {
+			summary := fmt.Sprintf("TLS certificate %s renewed, expires at %s", secretName, certificate.NotAfter.Format(time.RFC3339))
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	// Track the expiring certificate so the renewal or the deletion of the secret resolves the alert
+	getProblemSince(alertKey)
+	addOpenAlert(certificatesObjectKey, secretName)
+
+	summary := fmt.Sprintf("TLS certificate %s expires in %s", secretName, remaining.Round(time.Hour))
+	if remaining <= 0 {
+		summary = fmt.Sprintf("TLS certificate %s expired at %s", secretName, certificate.NotAfter.Format(time.RFC3339))
+	}
+	details := getCertificateDetails(secret, certificate, ingresses)
+	customDetails := getCertificateCustomDetails(secret, certificate, ingresses)
+	customDetails["expires_in"] = remaining.Round(time.Hour).String()
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, setting.Priority, labels, nil, nil, customDetails)
+}
+
+// getIngressCertificates gets the tls secrets referenced by ingresses one by one instead of listing all secrets,
+// the secrets that failed to load are returned as checked so a transient error doesn't resolve their alerts
+func getIngressCertificates(ingressSecrets map[string][]string, cfg *config.Config) ([]*api.Secret, []string) {
+	secretNames := make([]string, 0, len(ingressSecrets))
+	for secretName := range ingressSecrets {
+		secretNames = append(secretNames, secretName)
+	}
+	sort.Strings(secretNames)
+
+	secrets := make([]*api.Secret, 0, len(secretNames))
+	failed := make([]string, 0)
+	for _, secretName := range secretNames {
+		namespace, name, _ := strings.Cut(secretName, "/")
+		secret, err := cfg.KubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				log.Debug().Err(err).Str("secret", name).Str("namespace", namespace).Msg("Failed to get tls secret")
+				failed = append(failed, secretName)
+			}
+			continue
+		}
+		if secret.Type != api.SecretTypeTLS {
+			continue
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, failed
+}
+
+// getCertificateSecrets returns the tls secrets to check
+func getCertificateSecrets(ingressSecrets map[string][]string, cfg *config.Config) ([]*api.Secret, []string, error) {
+	if cfg.Alarms.Certificates.OnlyIngressReferenced {
+		secrets, failed := getIngressCertificates(ingressSecrets, cfg)
+		return secrets, failed, nil
+	}
+
+	selector := fields.OneTermEqualSelector("type", string(api.SecretTypeTLS)).String()
+	list, err := cfg.KubeClient.CoreV1().Secrets(getScopeNamespace(cfg)).List(context.TODO(), metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secrets := make([]*api.Secret, 0, len(list.Items))
+	for i := range list.Items {
+		if isNamespaceInScope(list.Items[i].GetNamespace(), cfg) {
+			secrets = append(secrets, &list.Items[i])
+		}
+	}
+	return secrets, nil, nil
+}
+
+func analyzeCertificates(cfg *config.Config) error {
+	if !cfg.Alarms.Certificates.Enabled {
+		return nil
+	}
+
+	ingressSecrets, err := getIngressSecrets(cfg)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to get ingresses")
+		if cfg.Alarms.Certificates.OnlyIngressReferenced {
+			return err
+		}
+	}
+
+	secrets, failed, err := getCertificateSecrets(ingressSecrets, cfg)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to get tls secrets")
+		return err
+	}
+
+	checked := append(make([]string, 0, len(secrets)+len(failed)), failed...)
+	for _, secret := range secrets {
+		secretName := fmt.Sprintf("%s/%s", secret.GetNamespace(), secret.GetName())
+		checked = append(checked, secretName)
+		analyzeCertificate(secret, ingressSecrets[secretName], cfg)
+	}
+
+	resolveRemovedCertificates(checked, cfg)
+	return nil
+}
+
+// resolveRemovedCertificates resolves the alerts of secrets that were deleted or are no longer checked since the last check
+func resolveRemovedCertificates(checked []string, cfg *config.Config) {
+	for _, secretName := range getOpenAlerts(certificatesObjectKey) {
+		if utils.StringContains(checked, secretName) {
+			continue
+		}
+
+		removeOpenAlert(certificatesObjectKey, secretName)
+		namespace, name, _ := strings.Cut(secretName, "/")
+		alertKey := getCertificateKey(namespace, name)
+		if clearProblemSince(alertKey) && cfg.Alarms.Certificates.SendResolveEvents {
+			labels := map[string]string{
+				"namespace": namespace,
+				"secret":    name,
+			}
+			summary := fmt.Sprintf("TLS certificate secret %s removed", secretName)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+	}
+}
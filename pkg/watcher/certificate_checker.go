@@ -0,0 +1,51 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var certificateCheckerCron *cron.Cron
+
+func startCertificateChecker(cfg *config.Config) {
+	certificateCheckerCron = cron.New()
+	// The tls secrets include their private keys, they are listed on a much coarser schedule than the other checks
+	certificateCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Alarms.Certificates.CheckInterval), func() {
+		checkCertificates(cfg)
+	})
+
+	log.Info().Msg("Starting certificates checker")
+	certificateCheckerCron.Start()
+}
+
+func stopCertificateChecker() {
+	if certificateCheckerCron != nil {
+		log.Info().Msg("Stopping certificates checker")
+		certificateCheckerCron.Stop()
+		certificateCheckerCron = nil
+	}
+}
+
+func checkCertificates(cfg *config.Config) {
+	defer memory.RecoverPanic("certificate-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping certificate check due to memory pressure")
+			return
+		}
+	}
+
+	log.Debug().Msg("Running certificates check")
+
+	err := analyzeCertificates(cfg)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check certificates")
+	}
+}
@@ -0,0 +1,73 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+func TestGetCertificateSetting(t *testing.T) {
+	certificates := func(warning bool, critical bool) *config.Config {
+		cfg := &config.Config{}
+		cfg.Alarms.Certificates.Warning = config.ConfigAlarmSettingWithDuration{Enabled: warning, Priority: "LOW", Duration: "720h"}
+		cfg.Alarms.Certificates.Critical = config.ConfigAlarmSettingWithDuration{Enabled: critical, Priority: "HIGH", Duration: "168h"}
+		return cfg
+	}
+
+	tests := []struct {
+		name      string
+		cfg       *config.Config
+		remaining time.Duration
+		want      string
+	}{
+		{
+			name:      "valid long enough",
+			cfg:       certificates(true, true),
+			remaining: 60 * 24 * time.Hour,
+			want:      "",
+		},
+		{
+			name:      "warning window",
+			cfg:       certificates(true, true),
+			remaining: 14 * 24 * time.Hour,
+			want:      "LOW",
+		},
+		{
+			name:      "critical window takes precedence",
+			cfg:       certificates(true, true),
+			remaining: 3 * 24 * time.Hour,
+			want:      "HIGH",
+		},
+		{
+			name:      "expired",
+			cfg:       certificates(true, true),
+			remaining: -time.Hour,
+			want:      "HIGH",
+		},
+		{
+			name:      "critical disabled falls back to warning",
+			cfg:       certificates(true, false),
+			remaining: 3 * 24 * time.Hour,
+			want:      "LOW",
+		},
+		{
+			name:      "warning disabled",
+			cfg:       certificates(false, true),
+			remaining: 14 * 24 * time.Hour,
+			want:      "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ""
+			if setting := getCertificateSetting(test.cfg, test.remaining); setting != nil {
+				got = setting.Priority
+			}
+			if got != test.want {
+				t.Errorf("getCertificateSetting() priority = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
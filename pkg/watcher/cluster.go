@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/iLert/ilert-go/v3"
 	"github.com/iLert/ilert-kube-agent/pkg/alert"
 	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/utils"
 	"github.com/rs/zerolog/log"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -27,6 +30,77 @@ func getConfigDetails(cfg *config.Config) string {
 	return details
 }
 
+// apiServerCheck is a single check of the verbose /readyz or /livez output e.g. [-]etcd failed: reason withheld
+type apiServerCheck struct {
+	Name   string
+	Passed bool
+	Output string
+}
+
+var apiServerHealthEndpoints = []string{"readyz", "livez"}
+
+func getAPIServerCheckKey(cfg *config.Config, endpoint string, check string) string {
+	return fmt.Sprintf("%s-%s-%s", getClusterKey(cfg), endpoint, check)
+}
+
+func parseAPIServerChecks(content string) []apiServerCheck {
+	checks := make([]apiServerCheck, 0)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 4 || (!strings.HasPrefix(line, "[+]") && !strings.HasPrefix(line, "[-]")) {
+			continue
+		}
+
+		name := strings.Fields(line[3:])[0]
+		checks = append(checks, apiServerCheck{
+			Name:   name,
+			Passed: strings.HasPrefix(line, "[+]"),
+			Output: line,
+		})
+	}
+	return checks
+}
+
+// getAPIServerChecks returns the individual checks of the endpoint, the apiserver answers with an error status if any check failed
+func getAPIServerChecks(cfg *config.Config, endpoint string) ([]apiServerCheck, string, error) {
+	content, err := cfg.KubeClient.Discovery().RESTClient().Get().AbsPath(fmt.Sprintf("/%s", endpoint)).Param("verbose", "").DoRaw(context.TODO())
+	checks := parseAPIServerChecks(string(content))
+	if err != nil && len(checks) == 0 {
+		return nil, "", err
+	}
+	return checks, string(content), nil
+}
+
+func analyzeAPIServerCheck(cfg *config.Config, endpoint string, check apiServerCheck, content string, labels map[string]string) bool {
+	if utils.StringContains(cfg.Alarms.Cluster.ExcludedChecks, check.Name) {
+		return true
+	}
+
+	alertKey := getAPIServerCheckKey(cfg, endpoint, check.Name)
+	if check.Passed {
+		if clearProblemSince(alertKey) && cfg.Alarms.Cluster.Enabled && cfg.Alarms.Cluster.SendResolveEvents {
+			summary := fmt.Sprintf("Cluster %s check %s recovered: %s", endpoint, check.Name, getClusterKey(cfg))
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return true
+	}
+
+	since := getProblemSince(alertKey)
+	if cfg.Alarms.Cluster.Enabled {
+		summary := fmt.Sprintf("Cluster %s check %s failed: %s", endpoint, check.Name, getClusterKey(cfg))
+		details := getConfigDetails(cfg)
+		details += fmt.Sprintf("\n\nCheck: \n%s\n\nOutput /%s?verbose: \n%s", check.Output, endpoint, content)
+		customDetails := map[string]interface{}{
+			"endpoint":        endpoint,
+			"check":           check.Name,
+			"output":          check.Output,
+			"unhealthy_since": since.Format(time.RFC3339),
+		}
+		alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Cluster.Priority, labels, nil, nil, customDetails)
+	}
+	return false
+}
+
 func analyzeClusterStatus(cfg *config.Config) error {
 	clusterKey := getClusterKey(cfg)
 
@@ -61,23 +135,24 @@ func analyzeClusterStatus(cfg *config.Config) error {
 		return err
 	}
 
-	// CLuster health check
-	alertKeyHealth := fmt.Sprintf("%s-health", clusterKey)
-	path := "/healthz"
-	content, err := cfg.KubeClient.Discovery().RESTClient().Get().AbsPath(path).DoRaw(context.TODO())
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to health info from apiserver")
-		return err
-	}
+	// Cluster health checks
+	failedChecks := make([]string, 0)
+	for _, endpoint := range apiServerHealthEndpoints {
+		checks, content, err := getAPIServerChecks(cfg, endpoint)
+		if err != nil {
+			log.Error().Err(err).Str("endpoint", endpoint).Msg("Failed to get health info from apiserver")
+			return err
+		}
 
-	contentStr := string(content)
-	if contentStr != "ok" {
-		summary := fmt.Sprintf("Cluster is not healthy: %s", clusterKey)
-		if cfg.Alarms.Cluster.Enabled {
-			details := getConfigDetails(cfg)
-			alert.CreateEvent(cfg, alertKeyHealth, summary, details, ilert.EventTypes.Alert, ilert.AlertPriorities.High, labels, nil, nil, nil)
+		for _, check := range checks {
+			if !analyzeAPIServerCheck(cfg, endpoint, check, content, labels) {
+				failedChecks = append(failedChecks, fmt.Sprintf("%s/%s", endpoint, check.Name))
+			}
 		}
-		return errors.New(summary)
+	}
+
+	if len(failedChecks) > 0 {
+		return fmt.Errorf("Cluster is not healthy: %s failed checks: %s", clusterKey, strings.Join(failedChecks, ", "))
 	}
 
 	return nil
@@ -0,0 +1,42 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var clusterCheckerCron *cron.Cron
+
+func startClusterChecker(cfg *config.Config) {
+	clusterCheckerCron = cron.New()
+	clusterCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkCluster(cfg)
+	})
+
+	log.Info().Msg("Starting cluster checker")
+	clusterCheckerCron.Start()
+}
+
+func stopClusterChecker() {
+	if clusterCheckerCron != nil {
+		log.Info().Msg("Stopping cluster checker")
+		clusterCheckerCron.Stop()
+		clusterCheckerCron = nil
+	}
+}
+
+func checkCluster(cfg *config.Config) {
+	defer memory.RecoverPanic("cluster-checker")
+
+	log.Debug().Msg("Running cluster check")
+
+	err := analyzeClusterStatus(cfg)
+	if err != nil {
+		log.Warn().Err(err).Msg("Cluster check failed")
+	}
+}
@@ -0,0 +1,50 @@
+package watcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAPIServerChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []apiServerCheck
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    []apiServerCheck{},
+		},
+		{
+			name:    "passed and failed checks",
+			content: "[+]ping ok\n[-]etcd failed: reason withheld\n[+]poststarthook/start-informers ok\nreadyz check failed",
+			want: []apiServerCheck{
+				{Name: "ping", Passed: true, Output: "[+]ping ok"},
+				{Name: "etcd", Passed: false, Output: "[-]etcd failed: reason withheld"},
+				{Name: "poststarthook/start-informers", Passed: true, Output: "[+]poststarthook/start-informers ok"},
+			},
+		},
+		{
+			name:    "indented lines",
+			content: "  [+]log ok  \n\t[-]informer-sync failed\n",
+			want: []apiServerCheck{
+				{Name: "log", Passed: true, Output: "[+]log ok"},
+				{Name: "informer-sync", Passed: false, Output: "[-]informer-sync failed"},
+			},
+		},
+		{
+			name:    "lines without check",
+			content: "ok\n[+]\n[?]unknown ok\nlivez check passed",
+			want:    []apiServerCheck{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseAPIServerChecks(test.content); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseAPIServerChecks() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
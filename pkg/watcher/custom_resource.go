@@ -0,0 +1,175 @@
+package watcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type customResourceCondition struct {
+	Type               string
+	Status             string
+	Reason             string
+	Message            string
+	LastTransitionTime string
+}
+
+func getCustomResourceGVR(setting config.ConfigAlarmsCustomResource) schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    setting.Group,
+		Version:  setting.Version,
+		Resource: setting.Resource,
+	}
+}
+
+func getCustomResourceName(setting config.ConfigAlarmsCustomResource) string {
+	return getCustomResourceGVR(setting).GroupResource().String()
+}
+
+func getCustomResourceKey(setting config.ConfigAlarmsCustomResource, obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return fmt.Sprintf("%s/%s-%s", getCustomResourceName(setting), obj.GetName(), setting.Condition)
+	}
+	return fmt.Sprintf("%s/%s/%s-%s", obj.GetNamespace(), getCustomResourceName(setting), obj.GetName(), setting.Condition)
+}
+
+func getCustomResourceConditions(obj *unstructured.Unstructured) []customResourceCondition {
+	conditions := make([]customResourceCondition, 0)
+	items, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return conditions
+	}
+
+	for _, item := range items {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _, _ := unstructured.NestedString(condition, "type")
+		status, _, _ := unstructured.NestedString(condition, "status")
+		reason, _, _ := unstructured.NestedString(condition, "reason")
+		message, _, _ := unstructured.NestedString(condition, "message")
+		lastTransitionTime, _, _ := unstructured.NestedString(condition, "lastTransitionTime")
+		conditions = append(conditions, customResourceCondition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: lastTransitionTime,
+		})
+	}
+	return conditions
+}
+
+func getEventLabelsFromCustomResource(setting config.ConfigAlarmsCustomResource, obj *unstructured.Unstructured) map[string]string {
+	return alert.WithObjectLabels(map[string]string{
+		"namespace":       obj.GetNamespace(),
+		"kind":            obj.GetKind(),
+		"resource":        getCustomResourceName(setting),
+		"name":            obj.GetName(),
+		"resourceVersion": obj.GetResourceVersion(),
+	}, obj.GetLabels())
+}
+
+func getCustomResourceDetails(setting config.ConfigAlarmsCustomResource, obj *unstructured.Unstructured, condition customResourceCondition, conditions []customResourceCondition) string {
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nKind: %s\nAPI version: %s\nCondition: %s=%s\nReason: %s\nMessage: %s",
+		obj.GetName(),
+		obj.GetNamespace(),
+		obj.GetKind(),
+		obj.GetAPIVersion(),
+		condition.Type,
+		condition.Status,
+		condition.Reason,
+		condition.Message)
+
+	if condition.LastTransitionTime != "" {
+		details += fmt.Sprintf("\nLast transition at: %s", condition.LastTransitionTime)
+	}
+
+	details += "\n\nConditions:"
+	for _, c := range conditions {
+		details += fmt.Sprintf("\n%s=%s %s: %s", c.Type, c.Status, c.Reason, c.Message)
+	}
+	return details
+}
+
+func getCustomResourceDisplayName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+}
+
+// resolveCustomResource resolves the condition alert of the custom resource if a problem was tracked
+func resolveCustomResource(obj *unstructured.Unstructured, setting config.ConfigAlarmsCustomResource, summary string, cfg *config.Config) {
+	alertKey := getCustomResourceKey(setting, obj)
+	if clearProblemSince(alertKey) && setting.SendResolveEvents {
+		labels := getEventLabelsFromCustomResource(setting, obj)
+		alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+	}
+}
+
+// analyzeCustomResourceDeleted resolves the condition alert of the removed custom resource
+func analyzeCustomResourceDeleted(obj *unstructured.Unstructured, setting config.ConfigAlarmsCustomResource, cfg *config.Config) {
+	summary := fmt.Sprintf("%s %s deleted", obj.GetKind(), getCustomResourceDisplayName(obj))
+	resolveCustomResource(obj, setting, summary, cfg)
+}
+
+func analyzeCustomResource(obj *unstructured.Unstructured, setting config.ConfigAlarmsCustomResource, cfg *config.Config) {
+	resourceName := getCustomResourceDisplayName(obj)
+	if obj.GetDeletionTimestamp() != nil {
+		summary := fmt.Sprintf("%s %s is being deleted", obj.GetKind(), resourceName)
+		resolveCustomResource(obj, setting, summary, cfg)
+		return
+	}
+
+	alertKey := getCustomResourceKey(setting, obj)
+	labels := getEventLabelsFromCustomResource(setting, obj)
+
+	conditions := getCustomResourceConditions(obj)
+	var condition *customResourceCondition
+	for i := range conditions {
+		if conditions[i].Type == setting.Condition {
+			condition = &conditions[i]
+			break
+		}
+	}
+
+	if condition == nil {
+		summary := fmt.Sprintf("%s %s no longer reports the %s condition", obj.GetKind(), resourceName, setting.Condition)
+		resolveCustomResource(obj, setting, summary, cfg)
+		return
+	}
+	if condition.Status != setting.Status {
+		summary := fmt.Sprintf("%s %s is %s=%s", obj.GetKind(), resourceName, condition.Type, condition.Status)
+		resolveCustomResource(obj, setting, summary, cfg)
+		return
+	}
+
+	since := getProblemSince(alertKey)
+	if time.Since(since) < getDuration(setting.Duration) {
+		return
+	}
+
+	summary := fmt.Sprintf("%s %s is %s=%s", obj.GetKind(), resourceName, condition.Type, condition.Status)
+	if condition.Reason != "" {
+		summary += fmt.Sprintf(" - %s", condition.Reason)
+	}
+	details := getCustomResourceDetails(setting, obj, *condition, conditions)
+	customDetails := map[string]interface{}{
+		"kind":            obj.GetKind(),
+		"api_version":     obj.GetAPIVersion(),
+		"name":            obj.GetName(),
+		"condition":       condition.Type,
+		"status":          condition.Status,
+		"reason":          condition.Reason,
+		"message":         condition.Message,
+		"unhealthy_since": since.Format(time.RFC3339),
+	}
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, setting.Priority, labels, nil, nil, customDetails)
+}
@@ -0,0 +1,66 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var customResourceCheckerCron *cron.Cron
+
+func startCustomResourceChecker(cfg *config.Config) {
+	customResourceCheckerCron = cron.New()
+	customResourceCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkCustomResources(cfg)
+	})
+
+	log.Info().Msg("Starting custom resources checker")
+	customResourceCheckerCron.Start()
+}
+
+func stopCustomResourceChecker() {
+	if customResourceCheckerCron != nil {
+		log.Info().Msg("Stopping custom resources checker")
+		customResourceCheckerCron.Stop()
+		customResourceCheckerCron = nil
+	}
+}
+
+func checkCustomResources(cfg *config.Config) {
+	defer memory.RecoverPanic("custom-resource-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping custom resource check due to memory pressure")
+			return
+		}
+	}
+
+	log.Debug().Msg("Running custom resources check")
+
+	for _, informer := range GetCustomResourceInformers() {
+		// Missing resource definitions or permissions never sync, so only skip the affected resource
+		if !informer.Informer.HasSynced() {
+			log.Warn().Str("resource", getCustomResourceName(informer.Setting)).Msg("Custom resource informer cache not synced, skipping custom resource check")
+			continue
+		}
+
+		for _, item := range informer.Informer.GetStore().List() {
+			obj, ok := item.(*unstructured.Unstructured)
+			if !ok {
+				log.Debug().Msg("Failed to convert object to custom resource, skipping")
+				continue
+			}
+			if !isInScope(obj, cfg) {
+				continue
+			}
+			analyzeCustomResource(obj, informer.Setting, cfg)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package watcher
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+// CustomResourceInformer is the dynamic informer of a configured custom resource
+type CustomResourceInformer struct {
+	Setting  config.ConfigAlarmsCustomResource
+	Informer cache.SharedInformer
+}
+
+var (
+	customResourceInformerStopper chan struct{}
+	customResourceInformers       []CustomResourceInformer
+)
+
+func startCustomResourceInformer(cfg *config.Config) {
+	dynamicFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(cfg.DynamicClient, 15*time.Minute, getScopeNamespace(cfg), func(options *metav1.ListOptions) {
+		options.LabelSelector = cfg.Settings.Scope.LabelSelector
+	})
+	customResourceInformerStopper = make(chan struct{})
+
+	informers := make([]CustomResourceInformer, 0, len(cfg.Alarms.CustomResources))
+	for _, setting := range cfg.Alarms.CustomResources {
+		setting := setting
+		informer := dynamicFactory.ForResource(getCustomResourceGVR(setting)).Informer()
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+				obj, ok := newObj.(*unstructured.Unstructured)
+				if !ok || !isInScope(obj, cfg) {
+					return
+				}
+				log.Debug().Str("resource", getCustomResourceName(setting)).Interface("name", obj.GetName()).Msg("Update custom resource")
+				analyzeCustomResource(obj, setting, cfg)
+			},
+			DeleteFunc: func(obj interface{}) {
+				resource, ok := getDeletedObject(obj).(*unstructured.Unstructured)
+				if !ok || !isInScope(resource, cfg) {
+					return
+				}
+				log.Debug().Str("resource", getCustomResourceName(setting)).Interface("name", resource.GetName()).Msg("Delete custom resource")
+				analyzeCustomResourceDeleted(resource, setting, cfg)
+			},
+		})
+		informers = append(informers, CustomResourceInformer{Setting: setting, Informer: informer})
+	}
+	customResourceInformers = informers
+
+	log.Info().Int("resources", len(informers)).Msg("Starting custom resource informers")
+
+	defer memory.RecoverPanic("custom-resource-informer")
+	dynamicFactory.Start(customResourceInformerStopper)
+}
+
+func stopCustomResourceInformer() {
+	if customResourceInformerStopper != nil {
+		log.Info().Msg("Stopping custom resource informers")
+		close(customResourceInformerStopper)
+		customResourceInformerStopper = nil
+	}
+}
+
+func GetCustomResourceInformers() []CustomResourceInformer {
+	return customResourceInformers
+}
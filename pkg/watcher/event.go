@@ -0,0 +1,192 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/utils"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+)
+
+// getEventKey returns the key of the event involved object
+func getEventKey(event *api.Event) string {
+	if event.InvolvedObject.Namespace == "" {
+		return fmt.Sprintf("%s/%s", strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", event.InvolvedObject.Namespace, strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name)
+}
+
+func getEventObjectName(event *api.Event) string {
+	if event.InvolvedObject.Namespace == "" {
+		return event.InvolvedObject.Name
+	}
+	return fmt.Sprintf("%s/%s", event.InvolvedObject.Namespace, event.InvolvedObject.Name)
+}
+
+func getEventAlertKey(event *api.Event) string {
+	return fmt.Sprintf("%s-%s", getEventKey(event), event.Reason)
+}
+
+func getEventCount(event *api.Event) int32 {
+	if event.Series != nil && event.Series.Count > 0 {
+		return event.Series.Count
+	}
+	if event.Count > 0 {
+		return event.Count
+	}
+	return 1
+}
+
+func getEventFirstSeen(event *api.Event) time.Time {
+	if !event.FirstTimestamp.IsZero() {
+		return event.FirstTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.GetCreationTimestamp().Time
+}
+
+func getEventLastSeen(event *api.Event) time.Time {
+	if event.Series != nil && !event.Series.LastObservedTime.IsZero() {
+		return event.Series.LastObservedTime.Time
+	}
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	return getEventFirstSeen(event)
+}
+
+func matchEventRule(rule config.ConfigAlarmsEventsRule, event *api.Event) bool {
+	if !utils.StringContains(rule.Reasons, event.Reason) {
+		return false
+	}
+	if len(rule.Kinds) > 0 && !utils.StringContains(rule.Kinds, event.InvolvedObject.Kind) {
+		return false
+	}
+	return true
+}
+
+// getEventOccurrences returns the number of new occurrences of the event within the window
+func getEventOccurrences(oldEvent *api.Event, event *api.Event, window time.Duration) int32 {
+	if time.Since(getEventLastSeen(event)) > window {
+		return 0
+	}
+	if oldEvent != nil {
+		return getEventCount(event) - getEventCount(oldEvent)
+	}
+	if time.Since(getEventFirstSeen(event)) > window {
+		return 1
+	}
+	return getEventCount(event)
+}
+
+func getEventLabels(event *api.Event) map[string]string {
+	labels := map[string]string{
+		"namespace": event.InvolvedObject.Namespace,
+		"kind":      event.InvolvedObject.Kind,
+		"name":      event.InvolvedObject.Name,
+		"reason":    event.Reason,
+	}
+	switch event.InvolvedObject.Kind {
+	case "Pod":
+		labels["podName"] = event.InvolvedObject.Name
+	case "Node":
+		labels["nodeName"] = event.InvolvedObject.Name
+	}
+	return labels
+}
+
+func getEventLinks(cfg *config.Config, event *api.Event) []ilert.AlertLink {
+	switch event.InvolvedObject.Kind {
+	case "Pod":
+		return renderLinks(cfg.Links.Pods, map[string]string{
+			"pod_name":      event.InvolvedObject.Name,
+			"pod_namespace": event.InvolvedObject.Namespace,
+		})
+	case "Node":
+		return renderLinks(cfg.Links.Nodes, map[string]string{
+			"node_name": event.InvolvedObject.Name,
+		})
+	}
+	return nil
+}
+
+func getEventDetails(event *api.Event, occurrences int64, window time.Duration) string {
+	details := fmt.Sprintf("Kind: %s\nName: %s\nNamespace: %s\nReason: %s\nMessage: %s\nSource: %s\nOccurrences: %d within %s\nFirst seen: %s\nLast seen: %s",
+		event.InvolvedObject.Kind,
+		event.InvolvedObject.Name,
+		event.InvolvedObject.Namespace,
+		event.Reason,
+		event.Message,
+		event.Source.Component,
+		occurrences,
+		window,
+		getEventFirstSeen(event).Format(time.RFC3339),
+		getEventLastSeen(event).Format(time.RFC3339))
+
+	return details
+}
+
+func analyzeEvent(oldEvent *api.Event, event *api.Event, cfg *config.Config) {
+	if !cfg.Alarms.Events.Enabled || event.Type != api.EventTypeWarning {
+		return
+	}
+
+	alertKey := getEventAlertKey(event)
+
+	for i, rule := range cfg.Alarms.Events.Rules {
+		if !matchEventRule(rule, event) {
+			continue
+		}
+
+		window := getDuration(rule.Window)
+		newOccurrences := getEventOccurrences(oldEvent, event, window)
+		if newOccurrences <= 0 {
+			return
+		}
+
+		counterKey := fmt.Sprintf("state:event-occurrences:%d:%s", i, alertKey)
+		occurrences, err := cache.Cache.State.IncrementItemWithinWindow(counterKey, int64(newOccurrences), window)
+		if err != nil {
+			log.Debug().Err(err).Str("counter_key", counterKey).Msg("Failed to count event occurrences")
+			return
+		}
+
+		log.Debug().
+			Str("alert_key", alertKey).
+			Str("reason", event.Reason).
+			Int64("occurrences", occurrences).
+			Int32("threshold", rule.Threshold).
+			Msg("Checking warning event")
+
+		if occurrences < int64(rule.Threshold) {
+			return
+		}
+
+		summary := fmt.Sprintf("%s %s %s - %s", event.InvolvedObject.Kind, getEventObjectName(event), event.Reason, event.Message)
+		details := getEventDetails(event, occurrences, window)
+		labels := getEventLabels(event)
+		links := getEventLinks(cfg, event)
+		customDetails := map[string]interface{}{
+			"kind":        event.InvolvedObject.Kind,
+			"name":        event.InvolvedObject.Name,
+			"namespace":   event.InvolvedObject.Namespace,
+			"reason":      event.Reason,
+			"message":     event.Message,
+			"source":      event.Source.Component,
+			"occurrences": occurrences,
+			"window":      rule.Window,
+			"first_seen":  getEventFirstSeen(event).Format(time.RFC3339),
+			"last_seen":   getEventLastSeen(event).Format(time.RFC3339),
+		}
+		alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, rule.Priority, labels, links, nil, customDetails)
+		return
+	}
+}
@@ -0,0 +1,56 @@
+package watcher
+
+import (
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var (
+	eventInformerStopper chan struct{}
+	eventInformer        cache.SharedInformer
+)
+
+func startEventInformer(cfg *config.Config) {
+	eventInformer = eventFactory.Core().V1().Events().Informer()
+	eventInformerStopper = make(chan struct{})
+	eventInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			event := obj.(*api.Event)
+			if !isNamespaceInScope(event.GetNamespace(), cfg) {
+				return
+			}
+			log.Debug().Interface("event", event.GetName()).Msg("Add Event")
+			analyzeEvent(nil, event, cfg)
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			oldEvent := oldObj.(*api.Event)
+			event := newObj.(*api.Event)
+			if !isNamespaceInScope(event.GetNamespace(), cfg) {
+				return
+			}
+			log.Debug().Interface("event", event.GetName()).Msg("Update Event")
+			analyzeEvent(oldEvent, event, cfg)
+		},
+	})
+
+	log.Info().Msg("Starting event informer")
+
+	defer memory.RecoverPanic("event-informer")
+	eventInformer.Run(eventInformerStopper)
+}
+
+func stopEventInformer() {
+	if eventInformerStopper != nil {
+		log.Info().Msg("Stopping event informer")
+		close(eventInformerStopper)
+		eventInformerStopper = nil
+	}
+}
+
+func GetEventInformer() cache.SharedInformer {
+	return eventInformer
+}
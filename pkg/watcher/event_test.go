@@ -0,0 +1,86 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetEventOccurrences(t *testing.T) {
+	now := time.Now()
+	event := func(count int32, firstSeen time.Duration, lastSeen time.Duration) *api.Event {
+		return &api.Event{
+			Count:          count,
+			FirstTimestamp: metav1.NewTime(now.Add(-firstSeen)),
+			LastTimestamp:  metav1.NewTime(now.Add(-lastSeen)),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		oldEvent *api.Event
+		event    *api.Event
+		window   time.Duration
+		want     int32
+	}{
+		{
+			name:   "new event within the window",
+			event:  event(3, 5*time.Minute, time.Minute),
+			window: 10 * time.Minute,
+			want:   3,
+		},
+		{
+			name:   "new event first seen before the window",
+			event:  event(30, time.Hour, time.Minute),
+			window: 10 * time.Minute,
+			want:   1,
+		},
+		{
+			name:   "last seen before the window",
+			event:  event(3, time.Hour, 30*time.Minute),
+			window: 10 * time.Minute,
+			want:   0,
+		},
+		{
+			name:     "updated event counts the delta",
+			oldEvent: event(5, time.Hour, 5*time.Minute),
+			event:    event(8, time.Hour, time.Minute),
+			window:   10 * time.Minute,
+			want:     3,
+		},
+		{
+			name:     "updated event without new occurrences",
+			oldEvent: event(5, time.Hour, time.Minute),
+			event:    event(5, time.Hour, time.Minute),
+			window:   10 * time.Minute,
+			want:     0,
+		},
+		{
+			name:   "event without count",
+			event:  event(0, time.Minute, time.Minute),
+			window: 10 * time.Minute,
+			want:   1,
+		},
+		{
+			name: "event series",
+			event: &api.Event{
+				Count:          4,
+				FirstTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+				LastTimestamp:  metav1.NewTime(now.Add(-time.Hour)),
+				Series:         &api.EventSeries{Count: 4, LastObservedTime: metav1.NewMicroTime(now.Add(-time.Minute))},
+			},
+			window: 10 * time.Minute,
+			want:   1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getEventOccurrences(test.oldEvent, test.event, test.window); got != test.want {
+				t.Errorf("getEventOccurrences() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,18 @@
 package watcher
 
-import api "k8s.io/api/core/v1"
+import (
+	"fmt"
+	"time"
+
+	"github.com/cbroglie/mustache"
+	"github.com/iLert/ilert-go/v3"
+	api "k8s.io/api/core/v1"
+
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+const problemStateTTL = 30 * 24 * time.Hour
 
 func getLabel(pod *api.Pod, label string) string {
 	label, exists := pod.ObjectMeta.Labels[label]
@@ -9,3 +21,100 @@ func getLabel(pod *api.Pod, label string) string {
 	}
 	return ""
 }
+
+func getDuration(value string) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+func renderLinks(settings []config.ConfigLinksSetting, mustacheValues map[string]string) []ilert.AlertLink {
+	links := make([]ilert.AlertLink, 0)
+	for _, link := range settings {
+		url, err := mustache.Render(link.Href, mustacheValues)
+		if err == nil && url != "" {
+			links = append(links, ilert.AlertLink{
+				Href: url,
+				Text: link.Name,
+			})
+		}
+	}
+	return links
+}
+
+func getProblemStateKey(alertKey string) string {
+	return fmt.Sprintf("state:since:%s", alertKey)
+}
+
+// getProblemSince returns the time a problem was first observed for the alert key and starts tracking it if needed
+func getProblemSince(alertKey string) time.Time {
+	stateKey := getProblemStateKey(alertKey)
+	value, err := cache.Cache.State.GetItem(stateKey)
+	if err == nil && value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err == nil {
+			return since
+		}
+	}
+
+	now := time.Now()
+	cache.Cache.State.SetItem(stateKey, now.Format(time.RFC3339), problemStateTTL)
+	return now
+}
+
+// clearProblemSince stops tracking the problem of the alert key and reports whether it was tracked
+func clearProblemSince(alertKey string) bool {
+	stateKey := getProblemStateKey(alertKey)
+	value, err := cache.Cache.State.GetItem(stateKey)
+	if err != nil || value == "" {
+		return false
+	}
+
+	cache.Cache.State.DeleteItem(stateKey)
+	return true
+}
+
+func getResourceAlertedKey(stateKey string) string {
+	return fmt.Sprintf("state:alerted:%s", stateKey)
+}
+
+// getResourceUsageState returns whether the resource usage percentage is healthy and whether to alarm,
+// the threshold has to be exceeded for the configured duration across consecutive checks and once alerted
+// the usage has to drop below threshold minus hysteresis to recover
+func getResourceUsageState(stateKey string, percentage float64, threshold int32, setting config.ConfigAlarmSettingResources) (bool, bool) {
+	alertedKey := getResourceAlertedKey(stateKey)
+	if percentage >= float64(threshold) {
+		since := getProblemSince(stateKey)
+		if time.Since(since) < getDuration(setting.For) {
+			return false, false
+		}
+		cache.Cache.State.SetItem(alertedKey, "true", problemStateTTL)
+		return false, true
+	}
+
+	// Every check below the threshold restarts the duration, the alerted state is only left below the hysteresis band
+	clearProblemSince(stateKey)
+	value, err := cache.Cache.State.GetItem(alertedKey)
+	alerted := err == nil && value != ""
+	if alerted && percentage >= float64(threshold-setting.Hysteresis) {
+		return false, false
+	}
+	if alerted {
+		cache.Cache.State.DeleteItem(alertedKey)
+	}
+	return true, false
+}
+
+// clearResourceUsageState stops tracking the resource usage and reports whether it was alerted
+func clearResourceUsageState(stateKey string) bool {
+	clearProblemSince(stateKey)
+	alertedKey := getResourceAlertedKey(stateKey)
+	value, err := cache.Cache.State.GetItem(alertedKey)
+	if err != nil || value == "" {
+		return false
+	}
+	cache.Cache.State.DeleteItem(alertedKey)
+	return true
+}
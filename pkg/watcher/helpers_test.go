@@ -0,0 +1,78 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+func TestGetResourceUsageState(t *testing.T) {
+	cache.Cache.Init(100)
+
+	type check struct {
+		percentage  float64
+		wantHealthy bool
+		wantAlarm   bool
+	}
+
+	tests := []struct {
+		name    string
+		setting config.ConfigAlarmSettingResources
+		checks  []check
+	}{
+		{
+			name:    "alarm without duration",
+			setting: config.ConfigAlarmSettingResources{Hysteresis: 5},
+			checks: []check{
+				{percentage: 50, wantHealthy: true},
+				{percentage: 90, wantAlarm: true},
+			},
+		},
+		{
+			name:    "duration not exceeded",
+			setting: config.ConfigAlarmSettingResources{For: "1h", Hysteresis: 5},
+			checks: []check{
+				{percentage: 95},
+				{percentage: 95},
+			},
+		},
+		{
+			name:    "stays alerted within the hysteresis band",
+			setting: config.ConfigAlarmSettingResources{Hysteresis: 5},
+			checks: []check{
+				{percentage: 92, wantAlarm: true},
+				{percentage: 87},
+				{percentage: 85},
+				{percentage: 84, wantHealthy: true},
+				{percentage: 87, wantHealthy: true},
+			},
+		},
+		{
+			name:    "recovers below the threshold without hysteresis",
+			setting: config.ConfigAlarmSettingResources{},
+			checks: []check{
+				{percentage: 90, wantAlarm: true},
+				{percentage: 89, wantHealthy: true},
+			},
+		},
+		{
+			name:    "not alerted below the threshold",
+			setting: config.ConfigAlarmSettingResources{Hysteresis: 5},
+			checks: []check{
+				{percentage: 87, wantHealthy: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, check := range test.checks {
+				healthy, alarm := getResourceUsageState(test.name, check.percentage, 90, test.setting)
+				if healthy != check.wantHealthy || alarm != check.wantAlarm {
+					t.Errorf("check %d: getResourceUsageState() = %v, %v, want %v, %v", i, healthy, alarm, check.wantHealthy, check.wantAlarm)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/commander"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	api "k8s.io/api/core/v1"
+)
+
+// TooManyReplicas is the scaling limited condition reason of an autoscaler pinned at max replicas
+const TooManyReplicas = "TooManyReplicas"
+
+// ScalingDisabled is the scaling active condition reason of an autoscaler whose target was scaled to zero on purpose
+const ScalingDisabled = "ScalingDisabled"
+
+type hpaMetric struct {
+	Name    string
+	Current string
+	Target  string
+}
+
+func getHPAKey(hpa *autoscaling.HorizontalPodAutoscaler) string {
+	return fmt.Sprintf("%s/hpa/%s", hpa.GetNamespace(), hpa.GetName())
+}
+
+func getHPACondition(hpa *autoscaling.HorizontalPodAutoscaler, conditionType autoscaling.HorizontalPodAutoscalerConditionType) *autoscaling.HorizontalPodAutoscalerCondition {
+	for i := range hpa.Status.Conditions {
+		if hpa.Status.Conditions[i].Type == conditionType {
+			return &hpa.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func getHPAWorkload(hpa *autoscaling.HorizontalPodAutoscaler) commander.WorkloadInfo {
+	workload := commander.WorkloadInfo{Name: hpa.Spec.ScaleTargetRef.Name}
+	switch hpa.Spec.ScaleTargetRef.Kind {
+	case "Deployment":
+		workload.Type = commander.WorkloadTypeDeployment
+	case "StatefulSet":
+		workload.Type = commander.WorkloadTypeStatefulSet
+	default:
+		workload.Type = commander.WorkloadType(strings.ToLower(hpa.Spec.ScaleTargetRef.Kind))
+	}
+	return workload
+}
+
+func getHPAMetricName(metricType autoscaling.MetricSourceType, resource *api.ResourceName, container string, metric *autoscaling.MetricIdentifier) string {
+	switch {
+	case resource != nil && container != "":
+		return fmt.Sprintf("%s/%s", container, *resource)
+	case resource != nil:
+		return string(*resource)
+	case metric != nil:
+		return metric.Name
+	}
+	return string(metricType)
+}
+
+func getHPAMetricTarget(target autoscaling.MetricTarget) string {
+	switch {
+	case target.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *target.AverageUtilization)
+	case target.AverageValue != nil:
+		return target.AverageValue.String()
+	case target.Value != nil:
+		return target.Value.String()
+	}
+	return ""
+}
+
+func getHPAMetricCurrent(current autoscaling.MetricValueStatus) string {
+	switch {
+	case current.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *current.AverageUtilization)
+	case current.AverageValue != nil:
+		return current.AverageValue.String()
+	case current.Value != nil:
+		return current.Value.String()
+	}
+	return ""
+}
+
+func getHPAMetricSpecName(spec autoscaling.MetricSpec) string {
+	switch spec.Type {
+	case autoscaling.ResourceMetricSourceType:
+		if spec.Resource != nil {
+			return getHPAMetricName(spec.Type, &spec.Resource.Name, "", nil)
+		}
+	case autoscaling.ContainerResourceMetricSourceType:
+		if spec.ContainerResource != nil {
+			return getHPAMetricName(spec.Type, &spec.ContainerResource.Name, spec.ContainerResource.Container, nil)
+		}
+	case autoscaling.PodsMetricSourceType:
+		if spec.Pods != nil {
+			return getHPAMetricName(spec.Type, nil, "", &spec.Pods.Metric)
+		}
+	case autoscaling.ObjectMetricSourceType:
+		if spec.Object != nil {
+			return getHPAMetricName(spec.Type, nil, "", &spec.Object.Metric)
+		}
+	case autoscaling.ExternalMetricSourceType:
+		if spec.External != nil {
+			return getHPAMetricName(spec.Type, nil, "", &spec.External.Metric)
+		}
+	}
+	return string(spec.Type)
+}
+
+func getHPAMetricSpecTarget(spec autoscaling.MetricSpec) string {
+	switch {
+	case spec.Resource != nil:
+		return getHPAMetricTarget(spec.Resource.Target)
+	case spec.ContainerResource != nil:
+		return getHPAMetricTarget(spec.ContainerResource.Target)
+	case spec.Pods != nil:
+		return getHPAMetricTarget(spec.Pods.Target)
+	case spec.Object != nil:
+		return getHPAMetricTarget(spec.Object.Target)
+	case spec.External != nil:
+		return getHPAMetricTarget(spec.External.Target)
+	}
+	return ""
+}
+
+func getHPAMetricStatusName(status autoscaling.MetricStatus) string {
+	switch status.Type {
+	case autoscaling.ResourceMetricSourceType:
+		if status.Resource != nil {
+			return getHPAMetricName(status.Type, &status.Resource.Name, "", nil)
+		}
+	case autoscaling.ContainerResourceMetricSourceType:
+		if status.ContainerResource != nil {
+			return getHPAMetricName(status.Type, &status.ContainerResource.Name, status.ContainerResource.Container, nil)
+		}
+	case autoscaling.PodsMetricSourceType:
+		if status.Pods != nil {
+			return getHPAMetricName(status.Type, nil, "", &status.Pods.Metric)
+		}
+	case autoscaling.ObjectMetricSourceType:
+		if status.Object != nil {
+			return getHPAMetricName(status.Type, nil, "", &status.Object.Metric)
+		}
+	case autoscaling.ExternalMetricSourceType:
+		if status.External != nil {
+			return getHPAMetricName(status.Type, nil, "", &status.External.Metric)
+		}
+	}
+	return string(status.Type)
+}
+
+func getHPAMetricStatusCurrent(status autoscaling.MetricStatus) string {
+	switch {
+	case status.Resource != nil:
+		return getHPAMetricCurrent(status.Resource.Current)
+	case status.ContainerResource != nil:
+		return getHPAMetricCurrent(status.ContainerResource.Current)
+	case status.Pods != nil:
+		return getHPAMetricCurrent(status.Pods.Current)
+	case status.Object != nil:
+		return getHPAMetricCurrent(status.Object.Current)
+	case status.External != nil:
+		return getHPAMetricCurrent(status.External.Current)
+	}
+	return ""
+}
+
+// getHPAMetrics matches the current metric values to the configured targets, the status metrics follow the spec order
+func getHPAMetrics(hpa *autoscaling.HorizontalPodAutoscaler) []hpaMetric {
+	metrics := make([]hpaMetric, 0, len(hpa.Spec.Metrics))
+	for _, spec := range hpa.Spec.Metrics {
+		metrics = append(metrics, hpaMetric{
+			Name:   getHPAMetricSpecName(spec),
+			Target: getHPAMetricSpecTarget(spec),
+		})
+	}
+	for _, status := range hpa.Status.CurrentMetrics {
+		name := getHPAMetricStatusName(status)
+		for i := range metrics {
+			if metrics[i].Name == name && metrics[i].Current == "" {
+				metrics[i].Current = getHPAMetricStatusCurrent(status)
+				break
+			}
+		}
+	}
+	return metrics
+}
+
+func getHPAMinReplicas(hpa *autoscaling.HorizontalPodAutoscaler) int32 {
+	if hpa.Spec.MinReplicas != nil {
+		return *hpa.Spec.MinReplicas
+	}
+	return 1
+}
+
+func getEventLabelsFromHPA(hpa *autoscaling.HorizontalPodAutoscaler) map[string]string {
+	workload := getHPAWorkload(hpa)
+	return alert.WithObjectLabels(map[string]string{
+		"namespace":       hpa.GetNamespace(),
+		"hpa":             hpa.GetName(),
+		"resourceVersion": hpa.GetResourceVersion(),
+		"workloadType":    string(workload.Type),
+		"workload":        workload.Name,
+	}, hpa.GetLabels())
+}
+
+func getHPADetails(hpa *autoscaling.HorizontalPodAutoscaler, metrics []hpaMetric) string {
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nScale target: %s/%s\nCurrent replicas: %d\nDesired replicas: %d\nMin replicas: %d\nMax replicas: %d",
+		hpa.GetName(),
+		hpa.GetNamespace(),
+		hpa.Spec.ScaleTargetRef.Kind,
+		hpa.Spec.ScaleTargetRef.Name,
+		hpa.Status.CurrentReplicas,
+		hpa.Status.DesiredReplicas,
+		getHPAMinReplicas(hpa),
+		hpa.Spec.MaxReplicas)
+
+	if len(metrics) > 0 {
+		details += "\n\nMetrics:"
+		for _, metric := range metrics {
+			details += fmt.Sprintf("\n%s: %s / %s", metric.Name, metric.Current, metric.Target)
+		}
+	}
+
+	if len(hpa.Status.Conditions) > 0 {
+		details += "\n\nConditions:"
+		for _, condition := range hpa.Status.Conditions {
+			details += fmt.Sprintf("\n%s=%s %s: %s", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+	return details
+}
+
+func getHPACustomDetails(hpa *autoscaling.HorizontalPodAutoscaler, metrics []hpaMetric) map[string]interface{} {
+	metricDetails := make([]map[string]string, 0, len(metrics))
+	for _, metric := range metrics {
+		metricDetails = append(metricDetails, map[string]string{
+			"name":    metric.Name,
+			"current": metric.Current,
+			"target":  metric.Target,
+		})
+	}
+	return map[string]interface{}{
+		"hpa":              hpa.GetName(),
+		"scale_target":     fmt.Sprintf("%s/%s", hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name),
+		"current_replicas": hpa.Status.CurrentReplicas,
+		"desired_replicas": hpa.Status.DesiredReplicas,
+		"min_replicas":     getHPAMinReplicas(hpa),
+		"max_replicas":     hpa.Spec.MaxReplicas,
+		"metrics":          metricDetails,
+	}
+}
+
+// getHPAProblem returns the reason an autoscaler is saturated or unable to scale, empty if healthy
+func getHPAProblem(hpa *autoscaling.HorizontalPodAutoscaler) string {
+	if condition := getHPACondition(hpa, autoscaling.AbleToScale); condition != nil && condition.Status == api.ConditionFalse {
+		return fmt.Sprintf("unable to scale - %s", condition.Reason)
+	}
+	if condition := getHPACondition(hpa, autoscaling.ScalingActive); condition != nil && condition.Status == api.ConditionFalse &&
+		condition.Reason != ScalingDisabled {
+		return fmt.Sprintf("scaling inactive - %s", condition.Reason)
+	}
+	condition := getHPACondition(hpa, autoscaling.ScalingLimited)
+	if hpa.Status.CurrentReplicas >= hpa.Spec.MaxReplicas &&
+		condition != nil && condition.Status == api.ConditionTrue && condition.Reason == TooManyReplicas {
+		return fmt.Sprintf("saturated at max replicas %d", hpa.Spec.MaxReplicas)
+	}
+	return ""
+}
+
+func analyzeHPA(hpa *autoscaling.HorizontalPodAutoscaler, cfg *config.Config) {
+	if !cfg.Alarms.Autoscalers.Enabled {
+		return
+	}
+
+	alertKey := getHPAKey(hpa)
+	labels := getEventLabelsFromHPA(hpa)
+	hpaName := fmt.Sprintf("%s/%s", hpa.GetNamespace(), hpa.GetName())
+
+	problem := getHPAProblem(hpa)
+	if problem == "" {
+		if clearProblemSince(alertKey) && cfg.Alarms.Autoscalers.SendResolveEvents {
+			summary := fmt.Sprintf("HorizontalPodAutoscaler %s recovered", hpaName)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	since := getProblemSince(alertKey)
+	if time.Since(since) < getDuration(cfg.Alarms.Autoscalers.Duration) {
+		return
+	}
+
+	metrics := getHPAMetrics(hpa)
+	summary := fmt.Sprintf("HorizontalPodAutoscaler %s %s", hpaName, problem)
+	details := getHPADetails(hpa, metrics)
+	links := getWorkloadLinks(cfg, hpa.GetNamespace(), getHPAWorkload(hpa))
+	customDetails := getHPACustomDetails(hpa, metrics)
+	customDetails["unhealthy_since"] = since.Format(time.RFC3339)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Autoscalers.Priority, labels, links, nil, customDetails)
+}
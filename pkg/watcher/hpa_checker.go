@@ -0,0 +1,70 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var hpaCheckerCron *cron.Cron
+
+func startHPAChecker(cfg *config.Config) {
+	hpaCheckerCron = cron.New()
+	hpaCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkHPAs(cfg)
+	})
+
+	log.Info().Msg("Starting hpa checker")
+	hpaCheckerCron.Start()
+}
+
+func stopHPAChecker() {
+	if hpaCheckerCron != nil {
+		log.Info().Msg("Stopping hpa checker")
+		hpaCheckerCron.Stop()
+		hpaCheckerCron = nil
+	}
+}
+
+func checkHPAs(cfg *config.Config) {
+	defer memory.RecoverPanic("hpa-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping hpa check due to memory pressure")
+			return
+		}
+	}
+
+	informer := GetHPAInformer()
+	if informer == nil {
+		log.Warn().Msg("HPA informer not available, skipping hpa check")
+		return
+	}
+
+	if !cache.WaitForCacheSync(nil, informer.HasSynced) {
+		log.Warn().Msg("HPA informer cache not synced, skipping hpa check")
+		return
+	}
+
+	log.Debug().Msg("Running hpa check")
+
+	for _, obj := range informer.GetStore().List() {
+		hpa, ok := obj.(*autoscaling.HorizontalPodAutoscaler)
+		if !ok {
+			log.Debug().Msg("Failed to convert object to hpa, skipping")
+			continue
+		}
+		if !isInScope(hpa, cfg) {
+			continue
+		}
+		analyzeHPA(hpa, cfg)
+	}
+}
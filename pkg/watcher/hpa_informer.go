@@ -0,0 +1,47 @@
+package watcher
+
+import (
+	"github.com/rs/zerolog/log"
+	autoscaling "k8s.io/api/autoscaling/v2"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var (
+	hpaInformerStopper chan struct{}
+	hpaInformer        cache.SharedInformer
+)
+
+func startHPAInformer(cfg *config.Config) {
+	hpaInformer = scopedFactory.Autoscaling().V2().HorizontalPodAutoscalers().Informer()
+	hpaInformerStopper = make(chan struct{})
+	hpaInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			hpa := newObj.(*autoscaling.HorizontalPodAutoscaler)
+			if !isInScope(hpa, cfg) {
+				return
+			}
+			log.Debug().Interface("hpa", hpa.GetName()).Msg("Update HorizontalPodAutoscaler")
+			analyzeHPA(hpa, cfg)
+		},
+	})
+
+	log.Info().Msg("Starting hpa informer")
+
+	defer memory.RecoverPanic("hpa-informer")
+	hpaInformer.Run(hpaInformerStopper)
+}
+
+func stopHPAInformer() {
+	if hpaInformerStopper != nil {
+		log.Info().Msg("Stopping hpa informer")
+		close(hpaInformerStopper)
+		hpaInformerStopper = nil
+	}
+}
+
+func GetHPAInformer() cache.SharedInformer {
+	return hpaInformer
+}
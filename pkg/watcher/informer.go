@@ -0,0 +1,14 @@
+package watcher
+
+import (
+	"k8s.io/client-go/tools/cache"
+)
+
+// getDeletedObject returns the deleted object of an informer delete event, a deletion missed by the watch e.g. after
+// a relist is delivered as tombstone with the last known state of the object
+func getDeletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
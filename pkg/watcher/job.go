@@ -0,0 +1,284 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/utils"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	batch "k8s.io/api/batch/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func getJobCronJobName(job *batch.Job) string {
+	for _, owner := range job.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+// getJobKey returns the cronjob key for scheduled jobs so the next successful run resolves the alert
+func getJobKey(job *batch.Job) string {
+	cronJobName := getJobCronJobName(job)
+	if cronJobName != "" {
+		return fmt.Sprintf("%s/%s", job.GetNamespace(), cronJobName)
+	}
+	return fmt.Sprintf("%s/%s", job.GetNamespace(), job.GetName())
+}
+
+func getCronJobKey(cronJob *batch.CronJob) string {
+	return fmt.Sprintf("%s/%s", cronJob.GetNamespace(), cronJob.GetName())
+}
+
+// getJobAlertsKey returns the object key the open alerts of a job or cronjob are tracked on, it must not collide with the pod keys
+func getJobAlertsKey(key string) string {
+	return fmt.Sprintf("job:%s", key)
+}
+
+// resolveJobAlert resolves the alert if it was raised
+func resolveJobAlert(cfg *config.Config, key string, alertKey string, summary string, labels map[string]string) {
+	if removeOpenAlert(getJobAlertsKey(key), alertKey) && cfg.Alarms.Jobs.SendResolveEvents {
+		alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+	}
+}
+
+func getJobCondition(job *batch.Job, conditionType batch.JobConditionType) *batch.JobCondition {
+	for i := range job.Status.Conditions {
+		if job.Status.Conditions[i].Type == conditionType && job.Status.Conditions[i].Status == api.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func isJobFinished(job *batch.Job) bool {
+	return getJobCondition(job, batch.JobComplete) != nil || getJobCondition(job, batch.JobFailed) != nil
+}
+
+func getEventLabelsFromJob(job *batch.Job) map[string]string {
+	labels := map[string]string{
+		"namespace":       job.GetNamespace(),
+		"job":             job.GetName(),
+		"resourceVersion": job.GetResourceVersion(),
+	}
+	cronJobName := getJobCronJobName(job)
+	if cronJobName != "" {
+		labels["cronjob"] = cronJobName
+	}
+	return alert.WithObjectLabels(labels, job.GetLabels())
+}
+
+func getJobDetails(job *batch.Job) string {
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nActive: %d\nSucceeded: %d\nFailed: %d",
+		job.GetName(),
+		job.GetNamespace(),
+		job.Status.Active,
+		job.Status.Succeeded,
+		job.Status.Failed)
+
+	cronJobName := getJobCronJobName(job)
+	if cronJobName != "" {
+		details += fmt.Sprintf("\nCronJob: %s", cronJobName)
+	}
+	if job.Status.StartTime != nil {
+		details += fmt.Sprintf("\nStarted at: %s", job.Status.StartTime.Format(time.RFC3339))
+	}
+	return details
+}
+
+func getJobCustomDetails(job *batch.Job, condition *batch.JobCondition) map[string]interface{} {
+	customDetails := map[string]interface{}{
+		"job":       job.GetName(),
+		"active":    job.Status.Active,
+		"succeeded": job.Status.Succeeded,
+		"failed":    job.Status.Failed,
+	}
+	if job.Spec.BackoffLimit != nil {
+		customDetails["backoff_limit"] = *job.Spec.BackoffLimit
+	}
+	if job.Spec.ActiveDeadlineSeconds != nil {
+		customDetails["active_deadline_seconds"] = *job.Spec.ActiveDeadlineSeconds
+	}
+	if job.Status.StartTime != nil {
+		customDetails["started_at"] = job.Status.StartTime.Format(time.RFC3339)
+	}
+	if condition != nil {
+		customDetails["reason"] = condition.Reason
+		customDetails["message"] = condition.Message
+	}
+	cronJobName := getJobCronJobName(job)
+	if cronJobName != "" {
+		customDetails["cronjob"] = cronJobName
+	}
+	return customDetails
+}
+
+// getJobLogs returns the logs of the latest failed job pod
+func getJobLogs(kubeClient *kubernetes.Clientset, job *batch.Job) []ilert.EventLog {
+	pods, err := kubeClient.CoreV1().Pods(job.GetNamespace()).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", job.GetName()),
+	})
+	if err != nil {
+		log.Debug().Err(err).Str("job", job.GetName()).Str("namespace", job.GetNamespace()).Msg("Failed to get job pods")
+		return nil
+	}
+
+	var failedPod *api.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != api.PodFailed || len(pod.Spec.Containers) == 0 {
+			continue
+		}
+		if failedPod == nil || pod.GetCreationTimestamp().After(failedPod.GetCreationTimestamp().Time) {
+			failedPod = pod
+		}
+	}
+	if failedPod == nil {
+		return nil
+	}
+
+	return getPodLogs(kubeClient, failedPod, failedPod.Spec.Containers[0].Name, false)
+}
+
+func analyzeJobStatus(job *batch.Job, cfg *config.Config) {
+	jobKey := getJobKey(job)
+	labels := getEventLabelsFromJob(job)
+
+	if getJobCondition(job, batch.JobComplete) != nil {
+		summary := fmt.Sprintf("Job %s/%s completed", job.GetNamespace(), job.GetName())
+		resolveJobAlert(cfg, jobKey, fmt.Sprintf("%s-failed", jobKey), summary, labels)
+		resolveJobAlert(cfg, jobKey, fmt.Sprintf("%s-running", jobKey), summary, labels)
+		return
+	}
+
+	condition := getJobCondition(job, batch.JobFailed)
+	if condition == nil {
+		return
+	}
+
+	summary := fmt.Sprintf("Job %s/%s is not running anymore", job.GetNamespace(), job.GetName())
+	resolveJobAlert(cfg, jobKey, fmt.Sprintf("%s-running", jobKey), summary, labels)
+
+	if !cfg.Alarms.Jobs.Failed.Enabled {
+		return
+	}
+
+	if utils.StringContains(cfg.Alarms.Jobs.Failed.ExcludedReasons, condition.Reason) {
+		log.Debug().
+			Str("job", job.GetName()).
+			Str("namespace", job.GetNamespace()).
+			Str("reason", condition.Reason).
+			Msg("Skipping alert for excluded job failure reason")
+		return
+	}
+
+	summary = fmt.Sprintf("Job %s/%s failed - %s", job.GetNamespace(), job.GetName(), condition.Reason)
+	details := getJobDetails(job)
+	details += fmt.Sprintf("\nReason: %s\nMessage: %s", condition.Reason, condition.Message)
+	jobLogs := getJobLogs(cfg.KubeClient, job)
+	customDetails := getJobCustomDetails(job, condition)
+	addOpenAlert(getJobAlertsKey(jobKey), fmt.Sprintf("%s-failed", jobKey))
+	alert.CreateEvent(cfg, fmt.Sprintf("%s-failed", jobKey), summary, details, ilert.EventTypes.Alert, cfg.Alarms.Jobs.Failed.Priority, labels, nil, jobLogs, customDetails)
+}
+
+func analyzeJobRunning(job *batch.Job, cfg *config.Config) {
+	if !cfg.Alarms.Jobs.LongRunning.Enabled || isJobFinished(job) || job.Status.StartTime == nil {
+		return
+	}
+
+	duration := getDuration(cfg.Alarms.Jobs.LongRunning.Duration)
+	running := time.Since(job.Status.StartTime.Time)
+	if running < duration {
+		return
+	}
+
+	labels := getEventLabelsFromJob(job)
+	summary := fmt.Sprintf("Job %s/%s running longer than %s", job.GetNamespace(), job.GetName(), cfg.Alarms.Jobs.LongRunning.Duration)
+	details := getJobDetails(job)
+	details += fmt.Sprintf("\nRunning for: %s", running.Round(time.Second))
+	customDetails := getJobCustomDetails(job, nil)
+	customDetails["running_for"] = running.Round(time.Second).String()
+	addOpenAlert(getJobAlertsKey(getJobKey(job)), fmt.Sprintf("%s-running", getJobKey(job)))
+	alert.CreateEvent(cfg, fmt.Sprintf("%s-running", getJobKey(job)), summary, details, ilert.EventTypes.Alert, cfg.Alarms.Jobs.LongRunning.Priority, labels, nil, nil, customDetails)
+}
+
+func getCronJobSchedule(cronJob *batch.CronJob) (cron.Schedule, error) {
+	spec := cronJob.Spec.Schedule
+	if cronJob.Spec.TimeZone != nil && *cronJob.Spec.TimeZone != "" {
+		spec = fmt.Sprintf("CRON_TZ=%s %s", *cronJob.Spec.TimeZone, spec)
+	}
+	return cron.ParseStandard(spec)
+}
+
+// getCronJobMissedSchedule returns the time the next run was expected at and whether it is overdue by more than the tolerance,
+// a failed run was still scheduled and is reported by the job failed alarm
+func getCronJobMissedSchedule(cronJob *batch.CronJob, schedule cron.Schedule, now time.Time, tolerance time.Duration) (time.Time, bool) {
+	lastScheduleTime := cronJob.GetCreationTimestamp().Time
+	if cronJob.Status.LastScheduleTime != nil {
+		lastScheduleTime = cronJob.Status.LastScheduleTime.Time
+	}
+	expectedTime := schedule.Next(lastScheduleTime)
+	return expectedTime, now.After(expectedTime.Add(tolerance))
+}
+
+func analyzeCronJobSchedule(cronJob *batch.CronJob, cfg *config.Config) {
+	if !cfg.Alarms.Jobs.MissedSchedule.Enabled || (cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend) {
+		return
+	}
+
+	schedule, err := getCronJobSchedule(cronJob)
+	if err != nil {
+		log.Debug().Err(err).Str("cronjob", cronJob.GetName()).Str("namespace", cronJob.GetNamespace()).Msg("Failed to parse cronjob schedule")
+		return
+	}
+
+	cronJobKey := getCronJobKey(cronJob)
+	alertKey := fmt.Sprintf("%s-missed", cronJobKey)
+	labels := alert.WithObjectLabels(map[string]string{
+		"namespace":       cronJob.GetNamespace(),
+		"cronjob":         cronJob.GetName(),
+		"resourceVersion": cronJob.GetResourceVersion(),
+	}, cronJob.GetLabels())
+
+	expectedTime, missed := getCronJobMissedSchedule(cronJob, schedule, time.Now(), getDuration(cfg.Alarms.Jobs.MissedSchedule.Duration))
+	if !missed {
+		summary := fmt.Sprintf("CronJob %s/%s is on schedule", cronJob.GetNamespace(), cronJob.GetName())
+		resolveJobAlert(cfg, cronJobKey, alertKey, summary, labels)
+		return
+	}
+
+	summary := fmt.Sprintf("CronJob %s/%s missed schedule - not scheduled since %s", cronJob.GetNamespace(), cronJob.GetName(), expectedTime.Format(time.RFC3339))
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nSchedule: %s\nExpected run at: %s",
+		cronJob.GetName(),
+		cronJob.GetNamespace(),
+		cronJob.Spec.Schedule,
+		expectedTime.Format(time.RFC3339))
+	customDetails := map[string]interface{}{
+		"cronjob":         cronJob.GetName(),
+		"schedule":        cronJob.Spec.Schedule,
+		"expected_run_at": expectedTime.Format(time.RFC3339),
+		"active":          len(cronJob.Status.Active),
+	}
+	if cronJob.Spec.TimeZone != nil {
+		customDetails["time_zone"] = *cronJob.Spec.TimeZone
+	}
+	if cronJob.Status.LastSuccessfulTime != nil {
+		details += fmt.Sprintf("\nLast successful run at: %s", cronJob.Status.LastSuccessfulTime.Format(time.RFC3339))
+		customDetails["last_successful_time"] = cronJob.Status.LastSuccessfulTime.Format(time.RFC3339)
+	}
+	if cronJob.Status.LastScheduleTime != nil {
+		details += fmt.Sprintf("\nLast scheduled at: %s", cronJob.Status.LastScheduleTime.Format(time.RFC3339))
+		customDetails["last_schedule_time"] = cronJob.Status.LastScheduleTime.Format(time.RFC3339)
+	}
+	addOpenAlert(getJobAlertsKey(cronJobKey), alertKey)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Jobs.MissedSchedule.Priority, labels, nil, nil, customDetails)
+}
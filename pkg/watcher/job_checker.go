@@ -0,0 +1,85 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var jobCheckerCron *cron.Cron
+
+func startJobChecker(cfg *config.Config) {
+	jobCheckerCron = cron.New()
+	jobCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkJobs(cfg)
+	})
+
+	log.Info().Msg("Starting jobs checker")
+	jobCheckerCron.Start()
+}
+
+func stopJobChecker() {
+	if jobCheckerCron != nil {
+		log.Info().Msg("Stopping jobs checker")
+		jobCheckerCron.Stop()
+		jobCheckerCron = nil
+	}
+}
+
+func checkJobs(cfg *config.Config) {
+	defer memory.RecoverPanic("job-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping job check due to memory pressure")
+			return
+		}
+	}
+
+	jobInformer := GetJobInformer()
+	cronJobInformer := GetCronJobInformer()
+	if jobInformer == nil || cronJobInformer == nil {
+		log.Warn().Msg("Job informers not available, skipping job check")
+		return
+	}
+
+	if !cache.WaitForCacheSync(nil, jobInformer.HasSynced, cronJobInformer.HasSynced) {
+		log.Warn().Msg("Job informers cache not synced, skipping job check")
+		return
+	}
+
+	log.Debug().Msg("Running jobs check")
+
+	jobs := jobInformer.GetStore().List()
+	for _, obj := range jobs {
+		job, ok := obj.(*batch.Job)
+		if !ok {
+			log.Debug().Msg("Failed to convert object to job, skipping")
+			continue
+		}
+		if !isInScope(job, cfg) {
+			continue
+		}
+		analyzeJobRunning(job, cfg)
+	}
+
+	cronJobs := cronJobInformer.GetStore().List()
+	for _, obj := range cronJobs {
+		cronJob, ok := obj.(*batch.CronJob)
+		if !ok {
+			log.Debug().Msg("Failed to convert object to cronjob, skipping")
+			continue
+		}
+		if !isInScope(cronJob, cfg) {
+			continue
+		}
+		analyzeCronJobSchedule(cronJob, cfg)
+	}
+}
@@ -0,0 +1,75 @@
+package watcher
+
+import (
+	"github.com/rs/zerolog/log"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var (
+	jobInformerStopper     chan struct{}
+	jobInformer            cache.SharedInformer
+	cronJobInformerStopper chan struct{}
+	cronJobInformer        cache.SharedInformer
+)
+
+func startJobInformer(cfg *config.Config) {
+	jobInformer = scopedFactory.Batch().V1().Jobs().Informer()
+	jobInformerStopper = make(chan struct{})
+	jobInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			oldJob := oldObj.(*batch.Job)
+			job := newObj.(*batch.Job)
+			if !isInScope(job, cfg) {
+				return
+			}
+			if isJobFinished(oldJob) && isJobFinished(job) {
+				return
+			}
+			log.Debug().Interface("job", job.GetName()).Msg("Update Job")
+			analyzeJobStatus(job, cfg)
+		},
+	})
+
+	log.Info().Msg("Starting job informer")
+
+	defer memory.RecoverPanic("job-informer")
+	jobInformer.Run(jobInformerStopper)
+}
+
+func stopJobInformer() {
+	if jobInformerStopper != nil {
+		log.Info().Msg("Stopping job informer")
+		close(jobInformerStopper)
+		jobInformerStopper = nil
+	}
+}
+
+func GetJobInformer() cache.SharedInformer {
+	return jobInformer
+}
+
+func startCronJobInformer(cfg *config.Config) {
+	cronJobInformer = scopedFactory.Batch().V1().CronJobs().Informer()
+	cronJobInformerStopper = make(chan struct{})
+
+	log.Info().Msg("Starting cronjob informer")
+
+	defer memory.RecoverPanic("cronjob-informer")
+	cronJobInformer.Run(cronJobInformerStopper)
+}
+
+func stopCronJobInformer() {
+	if cronJobInformerStopper != nil {
+		log.Info().Msg("Stopping cronjob informer")
+		close(cronJobInformerStopper)
+		cronJobInformerStopper = nil
+	}
+}
+
+func GetCronJobInformer() cache.SharedInformer {
+	return cronJobInformer
+}
@@ -0,0 +1,85 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	batch "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetCronJobMissedSchedule(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+	hourly, err := cron.ParseStandard("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		lastScheduleTime *time.Time
+		lastSuccessful   *time.Time
+		now              time.Time
+		wantExpected     time.Time
+		wantMissed       bool
+	}{
+		{
+			name:         "never scheduled within tolerance",
+			now:          time.Date(2024, 1, 1, 1, 5, 0, 0, time.UTC),
+			wantExpected: time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			wantMissed:   false,
+		},
+		{
+			name:         "never scheduled after tolerance",
+			now:          time.Date(2024, 1, 1, 1, 15, 0, 0, time.UTC),
+			wantExpected: time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			wantMissed:   true,
+		},
+		{
+			name:             "scheduled on time",
+			lastScheduleTime: timePtr(time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)),
+			now:              time.Date(2024, 1, 1, 5, 30, 0, 0, time.UTC),
+			wantExpected:     time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+			wantMissed:       false,
+		},
+		{
+			name:             "failed runs are not missed schedules",
+			lastScheduleTime: timePtr(time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)),
+			lastSuccessful:   timePtr(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)),
+			now:              time.Date(2024, 1, 1, 5, 30, 0, 0, time.UTC),
+			wantExpected:     time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+			wantMissed:       false,
+		},
+		{
+			name:             "not scheduled since",
+			lastScheduleTime: timePtr(time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)),
+			now:              time.Date(2024, 1, 1, 6, 11, 0, 0, time.UTC),
+			wantExpected:     time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+			wantMissed:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cronJob := &batch.CronJob{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}}
+			if test.lastScheduleTime != nil {
+				lastScheduleTime := metav1.NewTime(*test.lastScheduleTime)
+				cronJob.Status.LastScheduleTime = &lastScheduleTime
+			}
+			if test.lastSuccessful != nil {
+				lastSuccessful := metav1.NewTime(*test.lastSuccessful)
+				cronJob.Status.LastSuccessfulTime = &lastSuccessful
+			}
+
+			expected, missed := getCronJobMissedSchedule(cronJob, hourly, test.now, 10*time.Minute)
+			if !expected.Equal(test.wantExpected) || missed != test.wantMissed {
+				t.Errorf("getCronJobMissedSchedule() = %v, %v, want %v, %v", expected, missed, test.wantExpected, test.wantMissed)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,163 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type limitRangeConsumer struct {
+	Container  string
+	Usage      resource.Quantity
+	Percentage int64
+}
+
+func getLimitRangeKey(limitRange *api.LimitRange, resourceName api.ResourceName) string {
+	return fmt.Sprintf("%s/limitrange/%s-%s", limitRange.GetNamespace(), limitRange.GetName(), resourceName)
+}
+
+// getContainerLimitRangeUsage returns the container value the limit range max applies to, the limit and the request
+// for containers without limit
+func getContainerLimitRangeUsage(container api.Container, resourceName api.ResourceName) (resource.Quantity, bool) {
+	if quantity, ok := container.Resources.Limits[resourceName]; ok {
+		return quantity, true
+	}
+	quantity, ok := container.Resources.Requests[resourceName]
+	return quantity, ok
+}
+
+// getLimitRangeConsumers returns the containers whose limits reached the threshold of the limit range max, they can not grow
+// anymore and the next rollout with higher limits is rejected at admission
+func getLimitRangeConsumers(pods []*api.Pod, resourceName api.ResourceName, max resource.Quantity, threshold int32) []limitRangeConsumer {
+	consumers := make([]limitRangeConsumer, 0)
+	if max.IsZero() {
+		return consumers
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		containers := append(append([]api.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, container := range containers {
+			usage, ok := getContainerLimitRangeUsage(container, resourceName)
+			if !ok {
+				continue
+			}
+			percentage := getQuotaPercentage(usage, max)
+			if percentage < int64(threshold) {
+				continue
+			}
+			consumers = append(consumers, limitRangeConsumer{
+				Container:  fmt.Sprintf("%s/%s", pod.GetName(), container.Name),
+				Usage:      usage,
+				Percentage: percentage,
+			})
+		}
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		return consumers[i].Percentage > consumers[j].Percentage
+	})
+	return consumers
+}
+
+func analyzeLimitRangeResource(limitRange *api.LimitRange, resourceName api.ResourceName, max resource.Quantity, pods []*api.Pod, cfg *config.Config) {
+	setting := cfg.Alarms.Quotas.LimitRanges
+	alertKey := getLimitRangeKey(limitRange, resourceName)
+	limitRangeName := fmt.Sprintf("%s/%s", limitRange.GetNamespace(), limitRange.GetName())
+	labels := map[string]string{
+		"namespace":       limitRange.GetNamespace(),
+		"limitRange":      limitRange.GetName(),
+		"resource":        string(resourceName),
+		"resourceVersion": limitRange.GetResourceVersion(),
+	}
+
+	consumers := getLimitRangeConsumers(pods, resourceName, max, setting.Threshold)
+	if len(consumers) == 0 {
+		if clearProblemSince(alertKey) && cfg.Alarms.Quotas.SendResolveEvents {
+			summary := fmt.Sprintf("LimitRange %s %s container limits are below %d%% of max", limitRangeName, resourceName, setting.Threshold)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	since := getProblemSince(alertKey)
+	if len(consumers) > quotaTopConsumers {
+		consumers = consumers[:quotaTopConsumers]
+	}
+	consumerDetails := make([]map[string]interface{}, 0, len(consumers))
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nResource: %s\nContainer max: %s\n\nContainers:",
+		limitRange.GetName(),
+		limitRange.GetNamespace(),
+		resourceName,
+		max.String())
+	for _, consumer := range consumers {
+		details += fmt.Sprintf("\n- %s: %s (%d%%)", consumer.Container, consumer.Usage.String(), consumer.Percentage)
+		consumerDetails = append(consumerDetails, map[string]interface{}{
+			"container":  consumer.Container,
+			"usage":      consumer.Usage.String(),
+			"percentage": consumer.Percentage,
+		})
+	}
+
+	summary := fmt.Sprintf("LimitRange %s %s container limits reached %d%% of max", limitRangeName, resourceName, consumers[0].Percentage)
+	customDetails := map[string]interface{}{
+		"limit_range":     limitRange.GetName(),
+		"resource":        string(resourceName),
+		"max":             max.String(),
+		"threshold":       setting.Threshold,
+		"containers":      consumerDetails,
+		"unhealthy_since": since.Format(time.RFC3339),
+	}
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, setting.Priority, labels, nil, nil, customDetails)
+}
+
+func analyzeLimitRange(limitRange *api.LimitRange, cfg *config.Config) {
+	var pods []*api.Pod
+	for _, limit := range limitRange.Spec.Limits {
+		if limit.Type != api.LimitTypeContainer {
+			continue
+		}
+		for resourceName, max := range limit.Max {
+			if pods == nil {
+				var err error
+				pods, err = listNamespacePods(cfg.KubeClient, limitRange.GetNamespace())
+				if err != nil {
+					log.Debug().Err(err).Str("namespace", limitRange.GetNamespace()).Msg("Failed to get limit range namespace pods")
+					return
+				}
+			}
+			analyzeLimitRangeResource(limitRange, resourceName, max, pods, cfg)
+		}
+	}
+}
+
+func analyzeLimitRanges(cfg *config.Config) error {
+	if !cfg.Alarms.Quotas.Enabled || !cfg.Alarms.Quotas.LimitRanges.Enabled {
+		return nil
+	}
+
+	limitRanges, err := cfg.KubeClient.CoreV1().LimitRanges(getScopeNamespace(cfg)).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to get limit ranges")
+		return err
+	}
+
+	for i := range limitRanges.Items {
+		if !isNamespaceInScope(limitRanges.Items[i].GetNamespace(), cfg) {
+			continue
+		}
+		analyzeLimitRange(&limitRanges.Items[i], cfg)
+	}
+	return nil
+}
@@ -2,11 +2,15 @@ package watcher
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/rs/zerolog/log"
+	batch "k8s.io/api/batch/v1"
+	discovery "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
 	"github.com/iLert/ilert-kube-agent/pkg/config"
 	"github.com/iLert/ilert-kube-agent/pkg/logger"
 	"github.com/iLert/ilert-kube-agent/pkg/memory"
@@ -38,10 +42,34 @@ const (
 
 var containerTerminatedReasons = []string{Terminated, OOMKilled, Error, ContainerCannotRun, DeadlineExceeded, Evicted}
 
+// These are the valid reason for the pod eviction or rejection, OutOf is the prefix of the kubelet admission reasons e.g. OutOfcpu
+const (
+	Preempting               = "Preempting"
+	NodeLost                 = "NodeLost"
+	UnexpectedAdmissionError = "UnexpectedAdmissionError"
+	OutOfResource            = "OutOf"
+)
+
+var podEvictedReasons = []string{Evicted, Preempting, NodeLost, UnexpectedAdmissionError}
+
+// These are the container types reported in the alert labels
+const (
+	ContainerTypeApp       = "app"
+	ContainerTypeInit      = "init"
+	ContainerTypeEphemeral = "ephemeral"
+)
+
 // Start starts watcher
 func Start(cfg *config.Config) {
 	log.Info().Msg("Start watcher")
 
+	initFactories(cfg)
+
+	if cfg.Alarms.Cluster.Enabled {
+		memory.SafeGo("cluster-checker", func() {
+			startClusterChecker(cfg)
+		})
+	}
 	if cfg.Alarms.Pods.Enabled {
 		memory.SafeGo("pod-informer", func() {
 			startPodInformer(cfg)
@@ -58,21 +86,117 @@ func Start(cfg *config.Config) {
 			startNodeChecker(cfg)
 		})
 	}
+	if cfg.Alarms.Namespaces.Enabled {
+		memory.SafeGo("namespace-informer", func() {
+			startNamespaceInformer(cfg)
+		})
+		memory.SafeGo("namespace-checker", func() {
+			startNamespaceChecker(cfg)
+		})
+	}
+	if cfg.Alarms.Events.Enabled {
+		memory.SafeGo("event-informer", func() {
+			startEventInformer(cfg)
+		})
+	}
+	if cfg.Alarms.Jobs.Enabled {
+		memory.SafeGo("job-informer", func() {
+			startJobInformer(cfg)
+		})
+		memory.SafeGo("cronjob-informer", func() {
+			startCronJobInformer(cfg)
+		})
+		memory.SafeGo("job-checker", func() {
+			startJobChecker(cfg)
+		})
+	}
+	if cfg.Alarms.Workloads.Enabled {
+		memory.SafeGo("workload-informer", func() {
+			startWorkloadInformer(cfg)
+		})
+		memory.SafeGo("workload-checker", func() {
+			startWorkloadChecker(cfg)
+		})
+	}
+	if cfg.Alarms.Autoscalers.Enabled {
+		memory.SafeGo("hpa-informer", func() {
+			startHPAInformer(cfg)
+		})
+		memory.SafeGo("hpa-checker", func() {
+			startHPAChecker(cfg)
+		})
+	}
+	if cfg.Alarms.Volumes.Enabled {
+		memory.SafeGo("pvc-informer", func() {
+			startVolumeClaimInformer(cfg)
+		})
+		memory.SafeGo("volume-checker", func() {
+			startVolumeChecker(cfg)
+		})
+	}
+	if cfg.Alarms.Services.Enabled {
+		memory.SafeGo("service-informer", func() {
+			startServiceInformer(cfg)
+		})
+		memory.SafeGo("service-checker", func() {
+			startServiceChecker(cfg)
+		})
+	}
+	if len(cfg.Alarms.CustomResources) > 0 {
+		memory.SafeGo("custom-resource-informer", func() {
+			startCustomResourceInformer(cfg)
+		})
+		memory.SafeGo("custom-resource-checker", func() {
+			startCustomResourceChecker(cfg)
+		})
+	}
+	if cfg.Alarms.Certificates.Enabled {
+		memory.SafeGo("certificate-checker", func() {
+			startCertificateChecker(cfg)
+		})
+	}
+	if cfg.Alarms.Quotas.Enabled {
+		memory.SafeGo("quota-checker", func() {
+			startQuotaChecker(cfg)
+		})
+	}
 }
 
 // Stop Stops watcher
 func Stop() {
 	log.Info().Msg("Stop watcher")
 
+	stopClusterChecker()
 	stopPodInformer()
 	stopPodMetricsChecker()
 	stopNodeInformer()
 	stopNodeMetricsChecker()
+	stopNamespaceInformer()
+	stopNamespaceChecker()
+	stopEventInformer()
+	stopJobInformer()
+	stopCronJobInformer()
+	stopJobChecker()
+	stopWorkloadInformer()
+	stopWorkloadChecker()
+	stopHPAInformer()
+	stopHPAChecker()
+	stopVolumeClaimInformer()
+	stopVolumeChecker()
+	stopServiceInformer()
+	stopServiceChecker()
+	stopCustomResourceInformer()
+	stopCustomResourceChecker()
+	stopCertificateChecker()
+	stopQuotaChecker()
 
 	if sharedFactory != nil {
 		log.Info().Msg("Stopping shared informer factory")
 		sharedFactory = nil
 	}
+	scopedFactory = nil
+	podFactory = nil
+	eventFactory = nil
 }
 
 // RunOnce run watcher runs e.g. serverless call
@@ -81,6 +205,7 @@ func RunOnce(cfg *config.Config) {
 
 	cfg.Validate()
 	logger.Init(cfg.Settings.Log)
+	cache.Cache.Init(cfg.Settings.StateCacheSize)
 
 	err := analyzeClusterStatus(cfg)
 	if err != nil {
@@ -89,14 +214,22 @@ func RunOnce(cfg *config.Config) {
 	}
 
 	if cfg.Alarms.Pods.Enabled {
-		pods, err := cfg.KubeClient.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+		pods, err := cfg.KubeClient.CoreV1().Pods(getScopeNamespace(cfg)).List(context.TODO(), getPodScopeListOptions(cfg))
 		if err != nil {
 			log.Fatal().Err(err).Msg("Failed to get nodes from apiserver")
 		}
 
+		metricsAvailable := cfg.Alarms.Pods.Resources.Enabled && isMetricsAPIAvailable(cfg)
 		for _, pod := range pods.Items {
+			if !isInScope(&pod, cfg) {
+				continue
+			}
 			analyzePodStatus(&pod, cfg)
-			analyzePodResources(&pod, cfg)
+			analyzePodPending(&pod, cfg)
+			analyzePodTerminating(&pod, cfg)
+			if metricsAvailable {
+				analyzePodResources(&pod, cfg)
+			}
 		}
 	}
 	if cfg.Alarms.Nodes.Enabled {
@@ -106,9 +239,189 @@ func RunOnce(cfg *config.Config) {
 		}
 
 		log.Debug().Msg("Running nodes resource check")
+		metricsAvailable := cfg.Alarms.Nodes.Resources.Enabled && isMetricsAPIAvailable(cfg)
 		for _, node := range nodes.Items {
 			analyzeNodeStatus(&node, cfg)
-			analyzeNodeResources(&node, cfg)
+			analyzeNodeConditions(&node, cfg)
+			if metricsAvailable {
+				analyzeNodeResources(&node, cfg)
+			}
+		}
+	}
+	if cfg.Alarms.Namespaces.Enabled {
+		namespaces, err := cfg.KubeClient.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get namespaces from apiserver")
+		}
+
+		for _, namespace := range namespaces.Items {
+			if !isNamespaceInScope(namespace.GetName(), cfg) {
+				continue
+			}
+			analyzeNamespaceTerminating(&namespace, cfg)
+		}
+	}
+	if cfg.Alarms.Events.Enabled {
+		events, err := cfg.KubeClient.CoreV1().Events(getScopeNamespace(cfg)).List(context.TODO(), metav1.ListOptions{FieldSelector: "type=Warning"})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get events from apiserver")
+		}
+
+		for _, event := range events.Items {
+			if !isNamespaceInScope(event.GetNamespace(), cfg) {
+				continue
+			}
+			analyzeEvent(nil, &event, cfg)
+		}
+	}
+	if cfg.Alarms.Jobs.Enabled {
+		jobs, err := cfg.KubeClient.BatchV1().Jobs(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get jobs from apiserver")
+		}
+
+		// Only the latest run of a cronjob is relevant for its status
+		latestJobs := make(map[string]*batch.Job)
+		for i := range jobs.Items {
+			job := &jobs.Items[i]
+			if !isInScope(job, cfg) {
+				continue
+			}
+			jobKey := getJobKey(job)
+			if latestJob, ok := latestJobs[jobKey]; !ok || job.GetCreationTimestamp().After(latestJob.GetCreationTimestamp().Time) {
+				latestJobs[jobKey] = job
+			}
+		}
+		for _, job := range latestJobs {
+			analyzeJobStatus(job, cfg)
+			analyzeJobRunning(job, cfg)
+		}
+
+		cronJobs, err := cfg.KubeClient.BatchV1().CronJobs(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get cronjobs from apiserver")
+		}
+
+		for _, cronJob := range cronJobs.Items {
+			if !isInScope(&cronJob, cfg) {
+				continue
+			}
+			analyzeCronJobSchedule(&cronJob, cfg)
+		}
+	}
+	if cfg.Alarms.Workloads.Enabled {
+		deployments, err := cfg.KubeClient.AppsV1().Deployments(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get deployments from apiserver")
+		}
+		for _, deployment := range deployments.Items {
+			if !isInScope(&deployment, cfg) {
+				continue
+			}
+			analyzeDeployment(&deployment, cfg)
+		}
+
+		statefulSets, err := cfg.KubeClient.AppsV1().StatefulSets(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get statefulsets from apiserver")
+		}
+		for _, statefulSet := range statefulSets.Items {
+			if !isInScope(&statefulSet, cfg) {
+				continue
+			}
+			analyzeWorkloadStatus(getStatefulSetStatus(&statefulSet), cfg)
+		}
+
+		daemonSets, err := cfg.KubeClient.AppsV1().DaemonSets(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get daemonsets from apiserver")
+		}
+		for _, daemonSet := range daemonSets.Items {
+			if !isInScope(&daemonSet, cfg) {
+				continue
+			}
+			analyzeWorkloadStatus(getDaemonSetStatus(&daemonSet), cfg)
+		}
+	}
+	if cfg.Alarms.Autoscalers.Enabled {
+		hpas, err := cfg.KubeClient.AutoscalingV2().HorizontalPodAutoscalers(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get horizontal pod autoscalers from apiserver")
+		}
+		for _, hpa := range hpas.Items {
+			if !isInScope(&hpa, cfg) {
+				continue
+			}
+			analyzeHPA(&hpa, cfg)
+		}
+	}
+	if cfg.Alarms.Volumes.Enabled {
+		pvcs, err := cfg.KubeClient.CoreV1().PersistentVolumeClaims(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get persistent volume claims from apiserver")
+		}
+		for _, pvc := range pvcs.Items {
+			if !isInScope(&pvc, cfg) {
+				continue
+			}
+			analyzeVolumeClaimStatus(&pvc, cfg)
+		}
+
+		nodes, err := cfg.KubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get nodes from apiserver")
+		}
+		for _, node := range nodes.Items {
+			analyzeNodeVolumes(node.GetName(), cfg)
+		}
+	}
+	if cfg.Alarms.Services.Enabled {
+		services, err := cfg.KubeClient.CoreV1().Services(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get services from apiserver")
+		}
+
+		endpointSlices, err := cfg.KubeClient.DiscoveryV1().EndpointSlices(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get endpoint slices from apiserver")
+		}
+
+		serviceEndpointSlices := make(map[string][]*discovery.EndpointSlice)
+		for i := range endpointSlices.Items {
+			endpointSlice := &endpointSlices.Items[i]
+			serviceKey := fmt.Sprintf("%s/%s", endpointSlice.GetNamespace(), endpointSlice.GetLabels()[discovery.LabelServiceName])
+			serviceEndpointSlices[serviceKey] = append(serviceEndpointSlices[serviceKey], endpointSlice)
+		}
+		for _, service := range services.Items {
+			if !isInScope(&service, cfg) {
+				continue
+			}
+			analyzeService(&service, serviceEndpointSlices[fmt.Sprintf("%s/%s", service.GetNamespace(), service.GetName())], cfg)
+		}
+	}
+	for _, setting := range cfg.Alarms.CustomResources {
+		resources, err := cfg.DynamicClient.Resource(getCustomResourceGVR(setting)).Namespace(getScopeNamespace(cfg)).List(context.TODO(), getScopeListOptions(cfg))
+		if err != nil {
+			log.Error().Err(err).Str("resource", getCustomResourceName(setting)).Msg("Failed to get custom resources from apiserver")
+			continue
+		}
+		for i := range resources.Items {
+			if !isInScope(&resources.Items[i], cfg) {
+				continue
+			}
+			analyzeCustomResource(&resources.Items[i], setting, cfg)
+		}
+	}
+	if cfg.Alarms.Certificates.Enabled {
+		err := analyzeCertificates(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get tls secrets from apiserver")
+		}
+	}
+	if cfg.Alarms.Quotas.Enabled {
+		err := analyzeQuotas(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to get resource quotas from apiserver")
 		}
 	}
 
@@ -0,0 +1,188 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	api "k8s.io/api/core/v1"
+)
+
+// minMemorySamples is the number of samples required to fit a trend
+const minMemorySamples = 3
+
+type memorySample struct {
+	Time  time.Time `json:"time"`
+	Usage int64     `json:"usage"`
+}
+
+func getMemorySamplesKey(key string) string {
+	return fmt.Sprintf("state:memory-samples:%s", key)
+}
+
+func getPodMemoryPredictionKey(pod *api.Pod, containerName string) string {
+	return fmt.Sprintf("%s-memory-prediction", getPodResourceStateKey(pod, containerName, "memory"))
+}
+
+func getNodeMemoryPredictionKey(node *api.Node) string {
+	return fmt.Sprintf("%s-prediction", getNodeResourceStateKey(node, "memory"))
+}
+
+// clearMemoryPrediction stops tracking the prediction and the samples of the key and reports whether a prediction was alerted
+func clearMemoryPrediction(alertKey string) bool {
+	cache.Cache.State.DeleteItem(getMemorySamplesKey(alertKey))
+	return clearProblemSince(alertKey)
+}
+
+// clearPodMemoryPredictions clears the predictions of all containers of the pod and returns the alert keys of the alerted ones
+func clearPodMemoryPredictions(pod *api.Pod) []string {
+	alertKeys := make([]string, 0)
+	for _, container := range pod.Spec.Containers {
+		alertKey := getPodMemoryPredictionKey(pod, container.Name)
+		if clearMemoryPrediction(alertKey) {
+			alertKeys = append(alertKeys, alertKey)
+		}
+	}
+	return alertKeys
+}
+
+// addMemorySample appends the usage to the sample history of the key and returns the last samples
+func addMemorySample(key string, usage int64, samples int32) []memorySample {
+	stateKey := getMemorySamplesKey(key)
+	history := make([]memorySample, 0)
+	value, err := cache.Cache.State.GetItem(stateKey)
+	if err == nil && value != "" {
+		json.Unmarshal([]byte(value), &history)
+	}
+
+	history = append(history, memorySample{Time: time.Now(), Usage: usage})
+	if len(history) > int(samples) {
+		history = history[len(history)-int(samples):]
+	}
+
+	if data, err := json.Marshal(history); err == nil {
+		cache.Cache.State.SetItem(stateKey, string(data), problemStateTTL)
+	}
+	return history
+}
+
+// getMemoryTimeToLimit fits a linear trend to the samples with least squares and returns the projected time until the usage reaches the limit,
+// false if there are not enough samples or the usage is not growing
+func getMemoryTimeToLimit(history []memorySample, limit int64) (time.Duration, bool) {
+	if len(history) < minMemorySamples {
+		return 0, false
+	}
+
+	start := history[0].Time
+	n := float64(len(history))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range history {
+		x := sample.Time.Sub(start).Seconds()
+		y := float64(sample.Usage)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	if slope <= 0 {
+		return 0, false
+	}
+	intercept := (sumY - slope*sumX) / n
+
+	current := intercept + slope*history[len(history)-1].Time.Sub(start).Seconds()
+	if current >= float64(limit) {
+		return 0, true
+	}
+	return time.Duration((float64(limit) - current) / slope * float64(time.Second)), true
+}
+
+// getMemoryPrediction records the usage sample and returns the projected time to the limit if it is within the horizon
+func getMemoryPrediction(key string, usage int64, limit int64, setting config.ConfigAlarmSettingPrediction) (time.Duration, bool) {
+	history := addMemorySample(key, usage, setting.Samples)
+	timeToLimit, ok := getMemoryTimeToLimit(history, limit)
+	if !ok || timeToLimit > getDuration(setting.Horizon) {
+		return 0, false
+	}
+	return timeToLimit, true
+}
+
+func analyzePodMemoryPrediction(pod *api.Pod, container *api.Container, memoryUsage int64, labels map[string]string, cfg *config.Config) {
+	memoryLimit, ok := container.Resources.Limits.Memory().AsInt64()
+	if !ok || memoryLimit <= 0 {
+		return
+	}
+
+	setting := cfg.Alarms.Pods.Resources.Prediction
+	alertKey := getPodMemoryPredictionKey(pod, container.Name)
+	timeToLimit, predicted := getMemoryPrediction(alertKey, memoryUsage, memoryLimit, setting)
+	if !predicted {
+		if clearProblemSince(alertKey) && cfg.Alarms.Pods.SendResolveEvents {
+			summary := fmt.Sprintf("Pod %s/%s container %s memory usage is no longer projected to reach the limit", pod.GetNamespace(), pod.GetName(), container.Name)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	podCfg, _, enabled := getPodAlarmSettings(pod, labels, cfg)
+	if !enabled || !podCfg.Alarms.Pods.Resources.Prediction.Enabled {
+		return
+	}
+
+	summary := fmt.Sprintf("Pod %s/%s container %s memory limit projected to be reached in %s", pod.GetNamespace(), pod.GetName(), container.Name, timeToLimit.Round(time.Minute))
+	details := getPodDetailsWithUsageLimit(cfg.KubeClient, pod, humanize.Bytes(uint64(memoryUsage)), humanize.Bytes(uint64(memoryLimit)))
+	details += fmt.Sprintf("\nContainer: %s\nProjected to reach the limit in: %s\nHorizon: %s", container.Name, timeToLimit.Round(time.Minute), setting.Horizon)
+	customDetails := map[string]interface{}{
+		"container":     container.Name,
+		"memory_usage":  memoryUsage,
+		"memory_limit":  memoryLimit,
+		"time_to_limit": timeToLimit.Round(time.Minute).String(),
+		"horizon":       setting.Horizon,
+	}
+	// Track the alerted prediction so a flattening trend resolves the alert
+	getProblemSince(alertKey)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, podCfg.Alarms.Pods.Resources.Prediction.Priority, labels, getPodLinks(cfg, pod), nil, customDetails)
+}
+
+func analyzeNodeMemoryPrediction(node *api.Node, memoryUsage int64, labels map[string]string, cfg *config.Config) {
+	memoryLimit, ok := node.Status.Capacity.Memory().AsInt64()
+	if !ok || memoryLimit <= 0 {
+		return
+	}
+
+	setting := cfg.Alarms.Nodes.Resources.Prediction
+	alertKey := getNodeMemoryPredictionKey(node)
+	timeToLimit, predicted := getMemoryPrediction(alertKey, memoryUsage, memoryLimit, setting)
+	if !predicted {
+		removeOpenAlert(getNodeKey(node), alertKey)
+		if clearProblemSince(alertKey) && cfg.Alarms.Nodes.SendResolveEvents {
+			summary := fmt.Sprintf("Node %s memory usage is no longer projected to reach the capacity", node.GetName())
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	summary := fmt.Sprintf("Node %s memory capacity projected to be reached in %s", node.GetName(), timeToLimit.Round(time.Minute))
+	details := getNodeDetailsWithUsageLimit(cfg.KubeClient, node, humanize.Bytes(uint64(memoryUsage)), humanize.Bytes(uint64(memoryLimit)))
+	details += fmt.Sprintf("\nProjected to reach the capacity in: %s\nHorizon: %s", timeToLimit.Round(time.Minute), setting.Horizon)
+	customDetails := map[string]interface{}{
+		"memory_usage":  memoryUsage,
+		"memory_limit":  memoryLimit,
+		"time_to_limit": timeToLimit.Round(time.Minute).String(),
+		"horizon":       setting.Horizon,
+	}
+	// Track the alerted prediction so a flattening trend resolves the alert
+	getProblemSince(alertKey)
+	addOpenAlert(getNodeKey(node), alertKey)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, setting.Priority, labels, getNodeLinks(cfg, node), nil, customDetails)
+}
@@ -0,0 +1,76 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetMemoryTimeToLimit(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := func(usages ...int64) []memorySample {
+		history := make([]memorySample, 0, len(usages))
+		for i, usage := range usages {
+			history = append(history, memorySample{Time: start.Add(time.Duration(i) * time.Minute), Usage: usage})
+		}
+		return history
+	}
+
+	tests := []struct {
+		name      string
+		history   []memorySample
+		limit     int64
+		want      time.Duration
+		wantFound bool
+	}{
+		{
+			name:      "not enough samples",
+			history:   samples(100, 200),
+			limit:     1000,
+			wantFound: false,
+		},
+		{
+			name:      "flat usage",
+			history:   samples(500, 500, 500),
+			limit:     1000,
+			wantFound: false,
+		},
+		{
+			name:      "decreasing usage",
+			history:   samples(600, 500, 400),
+			limit:     1000,
+			wantFound: false,
+		},
+		{
+			name:      "linear growth",
+			history:   samples(100, 200, 300),
+			limit:     1000,
+			want:      7 * time.Minute,
+			wantFound: true,
+		},
+		{
+			name:      "limit already reached",
+			history:   samples(800, 900, 1000),
+			limit:     1000,
+			want:      0,
+			wantFound: true,
+		},
+		{
+			name:      "samples at the same time",
+			history:   []memorySample{{Time: start, Usage: 100}, {Time: start, Usage: 200}, {Time: start, Usage: 300}},
+			limit:     1000,
+			wantFound: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := getMemoryTimeToLimit(test.history, test.limit)
+			if found != test.wantFound {
+				t.Fatalf("getMemoryTimeToLimit() found = %v, want %v", found, test.wantFound)
+			}
+			if got.Round(time.Second) != test.want {
+				t.Errorf("getMemoryTimeToLimit() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
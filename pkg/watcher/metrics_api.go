@@ -0,0 +1,123 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	metricsAPIServicePath = "/apis/apiregistration.k8s.io/v1/apiservices/v1beta1.metrics.k8s.io"
+
+	// metricsAPICheckInterval prevents the pod and node checkers from querying the apiservice twice per run
+	metricsAPICheckInterval = 30 * time.Second
+)
+
+// apiServiceStatus is the subset of the apiregistration.k8s.io APIService used for the availability check
+type apiServiceStatus struct {
+	Status struct {
+		Conditions []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+var metricsAPIState = struct {
+	sync.Mutex
+	available bool
+	checkedAt time.Time
+}{available: true}
+
+func getMetricsAPIKey(cfg *config.Config) string {
+	return fmt.Sprintf("%s-metrics-api", getClusterKey(cfg))
+}
+
+// getMetricsAPIStatus returns whether the metrics.k8s.io apiservice is available and the reason if not
+func getMetricsAPIStatus(cfg *config.Config) (bool, string, string, error) {
+	content, err := cfg.KubeClient.Discovery().RESTClient().Get().AbsPath(metricsAPIServicePath).DoRaw(context.TODO())
+	if errors.IsNotFound(err) {
+		return false, "NotFound", "The apiservice is not registered, is metrics-server installed?", nil
+	}
+	if err != nil {
+		return false, "", "", err
+	}
+
+	apiService := &apiServiceStatus{}
+	err = json.Unmarshal(content, apiService)
+	if err != nil {
+		return false, "", "", err
+	}
+
+	for _, condition := range apiService.Status.Conditions {
+		if condition.Type == "Available" {
+			return condition.Status == "True", condition.Reason, condition.Message, nil
+		}
+	}
+	return false, "Unknown", "The apiservice has no available condition", nil
+}
+
+// isMetricsAPIAvailable checks the metrics.k8s.io apiservice, alerts once when it becomes unavailable and resolves when it returns.
+// The resource alarms are skipped while it is unavailable, errors of the check itself keep the last known state.
+func isMetricsAPIAvailable(cfg *config.Config) bool {
+	metricsAPIState.Lock()
+	defer metricsAPIState.Unlock()
+
+	if time.Since(metricsAPIState.checkedAt) < metricsAPICheckInterval {
+		return metricsAPIState.available
+	}
+
+	available, reason, message, err := getMetricsAPIStatus(cfg)
+	metricsAPIState.checkedAt = time.Now()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get metrics api status")
+		return metricsAPIState.available
+	}
+
+	clusterKey := getClusterKey(cfg)
+	alertKey := getMetricsAPIKey(cfg)
+	labels := map[string]string{
+		"cluster": clusterKey,
+	}
+
+	if available {
+		if clearProblemSince(alertKey) {
+			log.Info().Msg("Metrics api is available again, resuming resource checks")
+			if cfg.Alarms.Cluster.Enabled && cfg.Alarms.Cluster.SendResolveEvents {
+				summary := fmt.Sprintf("Metrics API is available again: %s", clusterKey)
+				alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+			}
+		}
+		metricsAPIState.available = true
+		return true
+	}
+
+	since := getProblemSince(alertKey)
+	if metricsAPIState.available {
+		log.Warn().Str("reason", reason).Str("message", message).Msg("Metrics api is not available, skipping resource checks")
+		if cfg.Alarms.Cluster.Enabled {
+			summary := fmt.Sprintf("Metrics API is not available, resource alarms are paused: %s", clusterKey)
+			details := getConfigDetails(cfg)
+			details += fmt.Sprintf("\n\nAPIService: v1beta1.metrics.k8s.io\nReason: %s\nMessage: %s", reason, message)
+			customDetails := map[string]interface{}{
+				"apiservice":      "v1beta1.metrics.k8s.io",
+				"reason":          reason,
+				"message":         message,
+				"unhealthy_since": since.Format(time.RFC3339),
+			}
+			alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Cluster.Priority, labels, nil, nil, customDetails)
+		}
+	}
+	metricsAPIState.available = false
+	return false
+}
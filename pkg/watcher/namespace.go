@@ -0,0 +1,90 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	api "k8s.io/api/core/v1"
+)
+
+func getNamespaceTerminatingKey(namespace *api.Namespace) string {
+	return fmt.Sprintf("%s-terminating", namespace.GetName())
+}
+
+// getNamespaceFinalizers returns the metadata and spec finalizers blocking the namespace deletion
+func getNamespaceFinalizers(namespace *api.Namespace) []string {
+	finalizers := make([]string, 0)
+	finalizers = append(finalizers, namespace.GetFinalizers()...)
+	for _, finalizer := range namespace.Spec.Finalizers {
+		finalizers = append(finalizers, string(finalizer))
+	}
+	return finalizers
+}
+
+func getEventLabelsFromNamespace(namespace *api.Namespace) map[string]string {
+	return alert.WithObjectLabels(map[string]string{
+		"namespace":       namespace.GetName(),
+		"resourceVersion": namespace.GetResourceVersion(),
+	}, namespace.GetLabels())
+}
+
+func getNamespaceDetails(namespace *api.Namespace, finalizers []string) string {
+	details := fmt.Sprintf("Name: %s\nPhase: %s\nDeletion requested at: %s",
+		namespace.GetName(),
+		namespace.Status.Phase,
+		namespace.GetDeletionTimestamp().Format(time.RFC3339))
+
+	if len(finalizers) > 0 {
+		details += fmt.Sprintf("\nBlocking finalizers: %s", strings.Join(finalizers, ", "))
+	}
+
+	// The namespace controller reports remaining content and failed deletions as conditions
+	conditions := ""
+	for _, condition := range namespace.Status.Conditions {
+		if condition.Status == api.ConditionTrue {
+			conditions += fmt.Sprintf("\n%s %s: %s", condition.Type, condition.Reason, condition.Message)
+		}
+	}
+	if conditions != "" {
+		details += "\n\nConditions:" + conditions
+	}
+	return details
+}
+
+func analyzeNamespaceTerminating(namespace *api.Namespace, cfg *config.Config) {
+	if !cfg.Alarms.Namespaces.Enabled || !cfg.Alarms.Namespaces.Terminating.Enabled || namespace.GetDeletionTimestamp() == nil {
+		return
+	}
+
+	since := namespace.GetDeletionTimestamp().Time
+	if time.Since(since) < getDuration(cfg.Alarms.Namespaces.Terminating.Duration) {
+		return
+	}
+
+	alertKey := getNamespaceTerminatingKey(namespace)
+	// Track the stuck namespace so its removal resolves the alert
+	getProblemSince(alertKey)
+
+	finalizers := getNamespaceFinalizers(namespace)
+	labels := getEventLabelsFromNamespace(namespace)
+	summary := fmt.Sprintf("Namespace %s stuck terminating since %s", namespace.GetName(), since.Format(time.RFC3339))
+	details := getNamespaceDetails(namespace, finalizers)
+	customDetails := map[string]interface{}{
+		"deletion_timestamp": since.Format(time.RFC3339),
+		"finalizers":         finalizers,
+	}
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Namespaces.Terminating.Priority, labels, nil, nil, customDetails)
+}
+
+// analyzeNamespaceDeleted resolves the stuck terminating alert once the namespace is gone
+func analyzeNamespaceDeleted(namespace *api.Namespace, cfg *config.Config) {
+	alertKey := getNamespaceTerminatingKey(namespace)
+	if clearProblemSince(alertKey) && cfg.Alarms.Namespaces.SendResolveEvents {
+		labels := getEventLabelsFromNamespace(namespace)
+		alert.CreateEvent(cfg, alertKey, fmt.Sprintf("Namespace %s deleted", namespace.GetName()), "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+	}
+}
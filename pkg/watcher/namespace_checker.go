@@ -0,0 +1,70 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var namespaceCheckerCron *cron.Cron
+
+func startNamespaceChecker(cfg *config.Config) {
+	namespaceCheckerCron = cron.New()
+	namespaceCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkNamespaces(cfg)
+	})
+
+	log.Info().Msg("Starting namespace checker")
+	namespaceCheckerCron.Start()
+}
+
+func stopNamespaceChecker() {
+	if namespaceCheckerCron != nil {
+		log.Info().Msg("Stopping namespace checker")
+		namespaceCheckerCron.Stop()
+		namespaceCheckerCron = nil
+	}
+}
+
+func checkNamespaces(cfg *config.Config) {
+	defer memory.RecoverPanic("namespace-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping namespace check due to memory pressure")
+			return
+		}
+	}
+
+	informer := GetNamespaceInformer()
+	if informer == nil {
+		log.Warn().Msg("Namespace informer not available, skipping namespace check")
+		return
+	}
+
+	if !cache.WaitForCacheSync(nil, informer.HasSynced) {
+		log.Warn().Msg("Namespace informer cache not synced, skipping namespace check")
+		return
+	}
+
+	log.Debug().Msg("Running namespace check")
+
+	for _, obj := range informer.GetStore().List() {
+		namespace, ok := obj.(*api.Namespace)
+		if !ok {
+			log.Debug().Msg("Failed to convert object to namespace, skipping")
+			continue
+		}
+		if !isNamespaceInScope(namespace.GetName(), cfg) {
+			continue
+		}
+		analyzeNamespaceTerminating(namespace, cfg)
+	}
+}
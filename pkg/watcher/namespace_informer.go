@@ -0,0 +1,47 @@
+package watcher
+
+import (
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var (
+	namespaceInformerStopper chan struct{}
+	namespaceInformer        cache.SharedInformer
+)
+
+func startNamespaceInformer(cfg *config.Config) {
+	namespaceInformer = sharedFactory.Core().V1().Namespaces().Informer()
+	namespaceInformerStopper = make(chan struct{})
+	namespaceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			namespace, ok := getDeletedObject(obj).(*api.Namespace)
+			if !ok || !isNamespaceInScope(namespace.GetName(), cfg) {
+				return
+			}
+			log.Debug().Interface("namespace", namespace.GetName()).Msg("Delete Namespace")
+			analyzeNamespaceDeleted(namespace, cfg)
+		},
+	})
+
+	log.Info().Msg("Starting namespace informer")
+
+	defer memory.RecoverPanic("namespace-informer")
+	namespaceInformer.Run(namespaceInformerStopper)
+}
+
+func stopNamespaceInformer() {
+	if namespaceInformerStopper != nil {
+		log.Info().Msg("Stopping namespace informer")
+		close(namespaceInformerStopper)
+		namespaceInformerStopper = nil
+	}
+}
+
+func GetNamespaceInformer() cache.SharedInformer {
+	return namespaceInformer
+}
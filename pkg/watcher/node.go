@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/cbroglie/mustache"
 	"github.com/dustin/go-humanize"
 	"github.com/iLert/ilert-go/v3"
 	"github.com/iLert/ilert-kube-agent/pkg/alert"
@@ -20,6 +19,10 @@ func getNodeKey(node *api.Node) string {
 	return fmt.Sprintf("%s", node.GetName())
 }
 
+func getNodeResourceStateKey(node *api.Node, resourceName string) string {
+	return fmt.Sprintf("%s-%s", getNodeKey(node), resourceName)
+}
+
 func getNodeDetails(kubeClient *kubernetes.Clientset, node *api.Node) string {
 	details := fmt.Sprintf("Name: %s\nArchitecture: %s\nOS image: %s\nOperating system: %s\nKernel version: %s\nContainer runtime version: %s\nKubelet version: %s",
 		node.GetName(),
@@ -45,6 +48,14 @@ func getNodeDetailsWithUsageLimit(kubeClient *kubernetes.Clientset, node *api.No
 	return details
 }
 
+func getEventLabelsFromNode(node *api.Node) map[string]string {
+	return alert.WithObjectLabels(map[string]string{
+		"namespace":       node.GetNamespace(),
+		"nodeName":        node.GetName(),
+		"resourceVersion": node.GetResourceVersion(),
+	}, node.GetLabels())
+}
+
 func getNodeMustacheValues(node *api.Node) map[string]string {
 	return map[string]string{
 		"node_name": node.GetName(),
@@ -52,48 +63,43 @@ func getNodeMustacheValues(node *api.Node) map[string]string {
 }
 
 func getNodeLinks(cfg *config.Config, node *api.Node) []ilert.AlertLink {
-	mustacheValues := getNodeMustacheValues(node)
-
-	links := make([]ilert.AlertLink, 0)
-	for _, link := range cfg.Links.Nodes {
-		url, err := mustache.Render(link.Href, mustacheValues)
-		if err == nil && url != "" {
-			links = append(links, ilert.AlertLink{
-				Href: url,
-				Text: link.Name,
-			})
-		}
-	}
-	return links
+	return renderLinks(cfg.Links.Nodes, getNodeMustacheValues(node))
 }
 
 func analyzeNodeStatus(node *api.Node, cfg *config.Config) {
 	nodeKey := getNodeKey(node)
 
-	labels := map[string]string{
-		"namespace":       node.GetNamespace(),
-		"nodeName":        node.GetName(),
-		"resourceVersion": node.GetResourceVersion(),
-	}
+	labels := getEventLabelsFromNode(node)
 
 	if node.Status.Phase == api.NodeTerminated && cfg.Alarms.Nodes.Terminate.Enabled {
 		summary := fmt.Sprintf("Node %s terminated", node.GetName())
 		details := getNodeDetails(cfg.KubeClient, node)
 		links := getNodeLinks(cfg, node)
+		addOpenAlert(nodeKey, nodeKey)
 		alert.CreateEvent(cfg, nodeKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Nodes.Terminate.Priority, labels, links, nil, nil)
 	}
 }
 
+// analyzeNodeDeleted resolves the alerts that are still open for the removed node and stops tracking its resource usage
+func analyzeNodeDeleted(node *api.Node, cfg *config.Config) {
+	nodeKey := getNodeKey(node)
+	clearResourceUsageState(getNodeResourceStateKey(node, "cpu"))
+	clearResourceUsageState(getNodeResourceStateKey(node, "memory"))
+	clearMemoryPrediction(getNodeMemoryPredictionKey(node))
+	for _, condition := range node.Status.Conditions {
+		clearProblemSince(getNodeConditionKey(node, condition.Type))
+	}
+
+	labels := getEventLabelsFromNode(node)
+	resolveOpenAlerts(cfg, nodeKey, fmt.Sprintf("Node %s removed", node.GetName()), labels, cfg.Alarms.Nodes.SendResolveEvents)
+}
+
 func analyzeNodeResources(node *api.Node, cfg *config.Config) error {
 	if !cfg.Alarms.Nodes.Resources.Enabled {
 		return nil
 	}
 
-	labels := map[string]string{
-		"namespace":       node.GetNamespace(),
-		"nodeName":        node.GetName(),
-		"resourceVersion": node.GetResourceVersion(),
-	}
+	labels := getEventLabelsFromNode(node)
 	nodeKey := getNodeKey(node)
 
 	nodeMetrics, err := cfg.MetricsClient.MetricsV1beta1().NodeMetricses().Get(context.TODO(), node.GetName(), metav1.GetOptions{})
@@ -127,11 +133,13 @@ func analyzeNodeResources(node *api.Node, cfg *config.Config) error {
 				Float64("limit", cpuLimit).
 				Float64("usage", cpuUsage).
 				Msg("Checking CPU limit")
-			if cpuUsage >= (float64(cfg.Alarms.Nodes.Resources.CPU.Threshold) * (cpuLimit / 100)) {
-				healthy = false
+			resourceHealthy, alarm := getResourceUsageState(getNodeResourceStateKey(node, "cpu"), cpuUsage*100/cpuLimit, cfg.Alarms.Nodes.Resources.CPU.Threshold, cfg.Alarms.Nodes.Resources)
+			healthy = healthy && resourceHealthy
+			if alarm {
 				summary := fmt.Sprintf("Node %s CPU limit reached > %d%%", node.GetName(), cfg.Alarms.Nodes.Resources.CPU.Threshold)
 				details := getNodeDetailsWithUsageLimit(cfg.KubeClient, node, fmt.Sprintf("%.3f CPU", cpuUsage), fmt.Sprintf("%.3f CPU", cpuLimit))
 				links := getNodeLinks(cfg, node)
+				addOpenAlert(nodeKey, nodeKey)
 				alert.CreateEvent(cfg, nodeKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Nodes.Resources.CPU.Priority, labels, links, nil, nil)
 			}
 		}
@@ -145,16 +153,25 @@ func analyzeNodeResources(node *api.Node, cfg *config.Config) error {
 				Int64("limit", memoryLimit).
 				Int64("usage", memoryUsage).
 				Msg("Checking memory limit")
-			if memoryUsage >= (int64(cfg.Alarms.Nodes.Resources.Memory.Threshold) * (memoryLimit / 100)) {
-				healthy = false
+			resourceHealthy, alarm := getResourceUsageState(getNodeResourceStateKey(node, "memory"), float64(memoryUsage)*100/float64(memoryLimit), cfg.Alarms.Nodes.Resources.Memory.Threshold, cfg.Alarms.Nodes.Resources)
+			healthy = healthy && resourceHealthy
+			if alarm {
 				summary := fmt.Sprintf("Node %s memory limit reached > %d%%", node.GetName(), cfg.Alarms.Nodes.Resources.Memory.Threshold)
 				details := getNodeDetailsWithUsageLimit(cfg.KubeClient, node, humanize.Bytes(uint64(memoryUsage)), humanize.Bytes(uint64(memoryLimit)))
 				links := getNodeLinks(cfg, node)
+				addOpenAlert(nodeKey, nodeKey)
 				alert.CreateEvent(cfg, nodeKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Nodes.Resources.Memory.Priority, labels, links, nil, nil)
 			}
 		}
 	}
 
+	if cfg.Alarms.Nodes.Resources.Prediction.Enabled && memoryUsage > 0 {
+		analyzeNodeMemoryPrediction(node, memoryUsage, labels, cfg)
+	}
+
+	if healthy {
+		removeOpenAlert(nodeKey, nodeKey)
+	}
 	if healthy && cfg.Alarms.Nodes.SendResolveEvents {
 		alert.CreateEvent(cfg, nodeKey, fmt.Sprintf("Node %s recovered", node.GetName()), "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
 	}
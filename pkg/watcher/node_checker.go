@@ -38,27 +38,29 @@ func checkNodes(cfg *config.Config) {
 	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
 		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
 		if pressureLevel == "critical" || pressureLevel == "emergency" {
-			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping node resource check due to memory pressure")
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping node check due to memory pressure")
 			return
 		}
 	}
 
-	if !cfg.Alarms.Nodes.Resources.Enabled {
+	if !cfg.Alarms.Nodes.Resources.Enabled && !cfg.Alarms.Nodes.Conditions.Enabled {
 		return
 	}
 
 	informer := GetNodeInformer()
 	if informer == nil {
-		log.Warn().Msg("Node informer not available, skipping resource check")
+		log.Warn().Msg("Node informer not available, skipping node check")
 		return
 	}
 
 	if !cache.WaitForCacheSync(nil, informer.HasSynced) {
-		log.Warn().Msg("Node informer cache not synced, skipping resource check")
+		log.Warn().Msg("Node informer cache not synced, skipping node check")
 		return
 	}
 
-	log.Debug().Msg("Running nodes resource check")
+	log.Debug().Msg("Running nodes check")
+
+	metricsAvailable := cfg.Alarms.Nodes.Resources.Enabled && isMetricsAPIAvailable(cfg)
 
 	nodes := informer.GetStore().List()
 	for _, obj := range nodes {
@@ -67,6 +69,9 @@ func checkNodes(cfg *config.Config) {
 			log.Debug().Msg("Failed to convert object to node, skipping")
 			continue
 		}
-		analyzeNodeResources(node, cfg)
+		analyzeNodeConditions(node, cfg)
+		if metricsAvailable {
+			analyzeNodeResources(node, cfg)
+		}
 	}
 }
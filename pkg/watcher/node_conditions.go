@@ -0,0 +1,111 @@
+package watcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/utils"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func getNodeConditionKey(node *api.Node, conditionType api.NodeConditionType) string {
+	return fmt.Sprintf("%s-%s", getNodeKey(node), conditionType)
+}
+
+// isNodeConditionProblem reports whether the condition describes an unhealthy node.
+// Ready is the only condition that is healthy when true, all other conditions
+// including custom ones added by e.g. node-problem-detector signal a problem when true.
+func isNodeConditionProblem(condition api.NodeCondition) bool {
+	if condition.Type == api.NodeReady {
+		return condition.Status != api.ConditionTrue
+	}
+	return condition.Status == api.ConditionTrue
+}
+
+func getNodeConditionSetting(cfg *config.Config, conditionType api.NodeConditionType) config.ConfigAlarmSettingCondition {
+	setting := config.ConfigAlarmSettingCondition{
+		Type:     string(conditionType),
+		Priority: cfg.Alarms.Nodes.Conditions.Priority,
+		Duration: cfg.Alarms.Nodes.Conditions.Duration,
+	}
+	for _, s := range cfg.Alarms.Nodes.Conditions.Types {
+		if s.Type != string(conditionType) {
+			continue
+		}
+		if s.Priority != "" {
+			setting.Priority = s.Priority
+		}
+		if s.Duration != "" {
+			setting.Duration = s.Duration
+		}
+		break
+	}
+	return setting
+}
+
+func getNodeConditionDetails(kubeClient *kubernetes.Clientset, node *api.Node, condition api.NodeCondition) string {
+	details := getNodeDetails(kubeClient, node)
+	details += fmt.Sprintf("\n\nCondition: %s\nStatus: %s\nReason: %s\nMessage: %s\nSince: %s",
+		condition.Type,
+		condition.Status,
+		condition.Reason,
+		condition.Message,
+		condition.LastTransitionTime.Format(time.RFC3339))
+	return details
+}
+
+func analyzeNodeConditions(node *api.Node, cfg *config.Config) {
+	if !cfg.Alarms.Nodes.Conditions.Enabled {
+		return
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if utils.StringContains(cfg.Alarms.Nodes.Conditions.ExcludedTypes, string(condition.Type)) {
+			continue
+		}
+
+		alertKey := getNodeConditionKey(node, condition.Type)
+		labels := getEventLabelsFromNode(node)
+		labels["condition"] = string(condition.Type)
+
+		if !isNodeConditionProblem(condition) {
+			// Only conditions that raised an alert are resolved
+			if !clearProblemSince(alertKey) {
+				continue
+			}
+			removeOpenAlert(getNodeKey(node), alertKey)
+			if cfg.Alarms.Nodes.SendResolveEvents {
+				summary := fmt.Sprintf("Node %s condition %s recovered", node.GetName(), condition.Type)
+				alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+			}
+			continue
+		}
+
+		setting := getNodeConditionSetting(cfg, condition.Type)
+		if time.Since(condition.LastTransitionTime.Time) < getDuration(setting.Duration) {
+			continue
+		}
+
+		summary := fmt.Sprintf("Node %s condition %s is %s", node.GetName(), condition.Type, condition.Status)
+		if condition.Reason != "" {
+			summary += fmt.Sprintf(" - %s", condition.Reason)
+		}
+		details := getNodeConditionDetails(cfg.KubeClient, node, condition)
+		links := getNodeLinks(cfg, node)
+		customDetails := map[string]interface{}{
+			"condition":      string(condition.Type),
+			"status":         string(condition.Status),
+			"reason":         condition.Reason,
+			"message":        condition.Message,
+			"since":          condition.LastTransitionTime.Format(time.RFC3339),
+			"last_heartbeat": condition.LastHeartbeatTime.Format(time.RFC3339),
+		}
+		getProblemSince(alertKey)
+		addOpenAlert(getNodeKey(node), alertKey)
+		alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, setting.Priority, labels, links, nil, customDetails)
+	}
+}
@@ -1,11 +1,12 @@
 package watcher
 
 import (
-	"time"
+	"context"
 
 	"github.com/rs/zerolog/log"
 	api "k8s.io/api/core/v1"
-	"k8s.io/client-go/informers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/iLert/ilert-kube-agent/pkg/config"
@@ -18,10 +19,6 @@ var (
 )
 
 func startNodeInformer(cfg *config.Config) {
-	if sharedFactory == nil {
-		sharedFactory = informers.NewSharedInformerFactory(cfg.KubeClient, 15*time.Minute)
-	}
-
 	nodeInformer = sharedFactory.Core().V1().Nodes().Informer()
 	nodeInformerStopper = make(chan struct{})
 	nodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -30,6 +27,14 @@ func startNodeInformer(cfg *config.Config) {
 			log.Debug().Interface("node_name", node.GetName()).Msg("Update Node")
 			analyzeNodeStatus(node, cfg)
 		},
+		DeleteFunc: func(obj interface{}) {
+			node, ok := getDeletedObject(obj).(*api.Node)
+			if !ok {
+				return
+			}
+			log.Debug().Interface("node_name", node.GetName()).Msg("Delete Node")
+			analyzeNodeDeleted(node, cfg)
+		},
 	})
 
 	log.Info().Msg("Starting node informer")
@@ -49,3 +54,41 @@ func stopNodeInformer() {
 func GetNodeInformer() cache.SharedInformer {
 	return nodeInformer
 }
+
+// getNode returns the node from the node informer, it is only fetched from the api if the node informer is not running
+// e.g. node alarms disabled or run once mode
+func getNode(kubeClient *kubernetes.Clientset, nodeName string) (*api.Node, error) {
+	if nodeInformer != nil && nodeInformer.HasSynced() {
+		obj, exists, err := nodeInformer.GetStore().GetByKey(nodeName)
+		if err == nil && exists {
+			if node, ok := obj.(*api.Node); ok {
+				return node, nil
+			}
+		}
+	}
+	return kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+}
+
+// listNodes returns the nodes from the node informer, they are only listed from the api if the node informer is not running
+func listNodes(kubeClient *kubernetes.Clientset) ([]*api.Node, error) {
+	if nodeInformer != nil && nodeInformer.HasSynced() {
+		objs := nodeInformer.GetStore().List()
+		nodes := make([]*api.Node, 0, len(objs))
+		for _, obj := range objs {
+			if node, ok := obj.(*api.Node); ok {
+				nodes = append(nodes, node)
+			}
+		}
+		return nodes, nil
+	}
+
+	nodeList, err := kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*api.Node, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes = append(nodes, &nodeList.Items[i])
+	}
+	return nodes, nil
+}
@@ -11,7 +11,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cbroglie/mustache"
 	"github.com/dustin/go-humanize"
 	"github.com/iLert/ilert-go/v3"
 	"github.com/iLert/ilert-kube-agent/pkg/alert"
@@ -65,10 +64,12 @@ func getPodDetailsWithStatus(kubeClient *kubernetes.Clientset, pod *api.Pod, con
 	return details
 }
 
-func getPodLogs(kubeClient *kubernetes.Clientset, pod *api.Pod, container string) []ilert.EventLog {
+// getPodLogs returns the container logs, previous returns the logs of the last terminated container instance
+func getPodLogs(kubeClient *kubernetes.Clientset, pod *api.Pod, container string, previous bool) []ilert.EventLog {
 	podLogOpts := api.PodLogOptions{
 		TailLines: utils.Int64(50),
 		Container: container,
+		Previous:  previous,
 	}
 
 	req := kubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &podLogOpts)
@@ -191,6 +192,22 @@ func getPodCustomDetails(containerStatus *api.ContainerStatus) map[string]interf
 	return customDetails
 }
 
+func getContainerDetailsWithStatus(kubeClient *kubernetes.Clientset, pod *api.Pod, containerStatus *api.ContainerStatus, containerType string) string {
+	details := getPodDetailsWithStatus(kubeClient, pod, containerStatus)
+	details += fmt.Sprintf("\nContainer: %s\nContainer type: %s", containerStatus.Name, containerType)
+	return details
+}
+
+func getContainerCustomDetails(containerStatus *api.ContainerStatus, containerType string) map[string]interface{} {
+	customDetails := getPodCustomDetails(containerStatus)
+	if customDetails == nil {
+		return nil
+	}
+	customDetails["container"] = containerStatus.Name
+	customDetails["container_type"] = containerType
+	return customDetails
+}
+
 func getPodMustacheValues(pod *api.Pod) map[string]string {
 	return map[string]string{
 		"pod_name":      pod.GetName(),
@@ -199,69 +216,163 @@ func getPodMustacheValues(pod *api.Pod) map[string]string {
 }
 
 func getPodLinks(cfg *config.Config, node *api.Pod) []ilert.AlertLink {
-	mustacheValues := getPodMustacheValues(node)
-
-	links := make([]ilert.AlertLink, 0)
-	for _, link := range cfg.Links.Pods {
-		url, err := mustache.Render(link.Href, mustacheValues)
-		if err == nil && url != "" {
-			links = append(links, ilert.AlertLink{
-				Href: url,
-				Text: link.Name,
-			})
-		}
+	return renderLinks(cfg.Links.Pods, getPodMustacheValues(node))
+}
+
+func getContainerLabels(labels map[string]string, containerType string, containerName string) map[string]string {
+	containerLabels := make(map[string]string, len(labels)+2)
+	for key, value := range labels {
+		containerLabels[key] = value
 	}
-	return links
+	containerLabels["containerType"] = containerType
+	containerLabels["container"] = containerName
+	return containerLabels
 }
 
-func analyzePodStatus(pod *api.Pod, cfg *config.Config) {
-	podKey := getPodKey(pod)
+func getContainerSummaryPrefix(pod *api.Pod, containerType string, containerName string) string {
+	if containerType == ContainerTypeApp {
+		return fmt.Sprintf("Pod %s/%s", pod.GetNamespace(), pod.GetName())
+	}
+	return fmt.Sprintf("Pod %s/%s %s container %s", pod.GetNamespace(), pod.GetName(), containerType, containerName)
+}
 
+func analyzePodStatus(pod *api.Pod, cfg *config.Config) {
 	labels := getEventLabelsFromPod(pod, cfg.KubeClient)
 
-	for _, containerStatus := range pod.Status.ContainerStatuses {
+	// The overrides of healthy pods are not resolved, most updates do not need any further lookup
+	if evictionReason, _ := getPodEvictionReason(pod); evictionReason == "" && !hasContainerProblem(pod) {
+		resolvePodContainerAlerts(pod, cfg, labels)
+		return
+	}
+
+	cfg, overrides, enabled := getPodAlarmSettings(pod, labels, cfg)
+	if !enabled {
+		return
+	}
+
+	// The containers of an evicted pod are terminated as well, the pod level reason is the root cause
+	if analyzePodEviction(pod, cfg, labels, overrides) {
+		return
+	}
+
+	appContainers := config.ConfigAlarmsContainers{
+		Enabled:   true,
+		Terminate: cfg.Alarms.Pods.Terminate,
+		Waiting:   cfg.Alarms.Pods.Waiting,
+		Restarts:  cfg.Alarms.Pods.Restarts,
+	}
+	if analyzeContainerStatuses(pod, cfg, pod.Status.ContainerStatuses, ContainerTypeApp, appContainers, labels, overrides) {
+		return
+	}
+
+	if cfg.Alarms.Pods.InitContainers.Enabled &&
+		analyzeContainerStatuses(pod, cfg, pod.Status.InitContainerStatuses, ContainerTypeInit, cfg.Alarms.Pods.InitContainers, labels, overrides) {
+		return
+	}
+
+	if cfg.Alarms.Pods.EphemeralContainers.Enabled &&
+		analyzeContainerStatuses(pod, cfg, pod.Status.EphemeralContainerStatuses, ContainerTypeEphemeral, cfg.Alarms.Pods.EphemeralContainers, labels, overrides) {
+		return
+	}
+
+	resolvePodContainerAlerts(pod, cfg, labels)
+}
+
+// hasContainerProblem reports whether any container is in a state the container alarms could alert on
+func hasContainerProblem(pod *api.Pod) bool {
+	statuses := append(append(append([]api.ContainerStatus{}, pod.Status.ContainerStatuses...), pod.Status.InitContainerStatuses...), pod.Status.EphemeralContainerStatuses...)
+	for _, containerStatus := range statuses {
+		if containerStatus.RestartCount > 0 {
+			return true
+		}
+		if containerStatus.State.Terminated != nil && utils.StringContains(containerTerminatedReasons, containerStatus.State.Terminated.Reason) {
+			return true
+		}
+		if containerStatus.State.Waiting != nil && utils.StringContains(containerWaitingReasons, containerStatus.State.Waiting.Reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePodContainerAlerts resolves the open terminate, waiting and restarts alerts of the pod once all containers are healthy
+func resolvePodContainerAlerts(pod *api.Pod, cfg *config.Config, labels map[string]string) {
+	releaseWorkloadPodAlerts(pod, cfg)
+	resolveOpenAlerts(cfg, getPodContainersKey(pod), fmt.Sprintf("Pod %s/%s containers recovered", pod.GetNamespace(), pod.GetName()), labels, cfg.Alarms.Pods.SendResolveEvents)
+}
+
+// analyzeContainerStatuses creates an alert for the first unhealthy container and reports whether an alert was created
+func analyzeContainerStatuses(pod *api.Pod, cfg *config.Config, containerStatuses []api.ContainerStatus, containerType string, settings config.ConfigAlarmsContainers, labels map[string]string, overrides alarmOverrides) bool {
+	for _, containerStatus := range containerStatuses {
+		summaryPrefix := getContainerSummaryPrefix(pod, containerType, containerStatus.Name)
+		containerLabels := getContainerLabels(labels, containerType, containerStatus.Name)
+
+		// The restarts are tracked before any other check so the rate window sees every observation
+		var restarts int32
+		if settings.Restarts.Enabled {
+			restarts = getContainerRestarts(pod, &containerStatus, settings.Restarts)
+		}
+
 		if containerStatus.State.Terminated != nil &&
 			utils.StringContains(containerTerminatedReasons, containerStatus.State.Terminated.Reason) &&
-			cfg.Alarms.Pods.Terminate.Enabled {
-			if utils.StringContains(cfg.Alarms.Pods.Terminate.ExcludedReasons, containerStatus.State.Terminated.Reason) {
+			settings.Terminate.Enabled {
+			if utils.StringContains(settings.Terminate.ExcludedReasons, containerStatus.State.Terminated.Reason) {
 				log.Debug().
 					Str("pod", pod.GetName()).
 					Str("namespace", pod.GetNamespace()).
+					Str("container", containerStatus.Name).
 					Str("reason", containerStatus.State.Terminated.Reason).
 					Msg("Skipping alert for excluded termination reason")
 				continue
 			}
-			summary := fmt.Sprintf("Pod %s/%s terminated - %s", pod.GetNamespace(), pod.GetName(), containerStatus.State.Terminated.Reason)
-			details := getPodDetailsWithStatus(cfg.KubeClient, pod, &containerStatus)
+			summary := fmt.Sprintf("%s terminated - %s", summaryPrefix, containerStatus.State.Terminated.Reason)
+			details := getContainerDetailsWithStatus(cfg.KubeClient, pod, &containerStatus, containerType)
 			links := getPodLinks(cfg, pod)
-			podLogs := getPodLogs(cfg.KubeClient, pod, containerStatus.Name)
-			customDetails := getPodCustomDetails(&containerStatus)
-			alert.CreateEvent(cfg, podKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.Terminate.Priority, labels, links, podLogs, customDetails)
-			break
+			podLogs := getPodLogs(cfg.KubeClient, pod, containerStatus.Name, false)
+			customDetails := addOverrideCustomDetails(getContainerCustomDetails(&containerStatus, containerType), overrides)
+			alertKey := getPodAlertKey(cfg, pod, labels, containerStatus.Name, containerStatus.State.Terminated.Reason)
+			alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, settings.Terminate.Priority, containerLabels, links, podLogs, customDetails)
+			return true
+		}
+
+		// A quickly restarted container is already waiting or running again, the OOM kill is only left in the last state
+		if analyzeContainerOOMKilled(pod, cfg, &containerStatus, containerType, containerLabels, overrides) {
+			return true
 		}
 
 		if containerStatus.State.Waiting != nil &&
 			utils.StringContains(containerWaitingReasons, containerStatus.State.Waiting.Reason) &&
-			cfg.Alarms.Pods.Waiting.Enabled {
-			summary := fmt.Sprintf("Pod %s/%s waiting - %s", pod.GetNamespace(), pod.GetName(), containerStatus.State.Waiting.Reason)
-			details := getPodDetailsWithStatus(cfg.KubeClient, pod, &containerStatus)
+			settings.Waiting.Enabled {
+			summary := fmt.Sprintf("%s waiting - %s", summaryPrefix, containerStatus.State.Waiting.Reason)
+			details := getContainerDetailsWithStatus(cfg.KubeClient, pod, &containerStatus, containerType)
 			links := getPodLinks(cfg, pod)
-			podLogs := getPodLogs(cfg.KubeClient, pod, containerStatus.Name)
-			customDetails := getPodCustomDetails(&containerStatus)
-			alert.CreateEvent(cfg, podKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.Waiting.Priority, labels, links, podLogs, customDetails)
-			break
+			podLogs := getPodLogs(cfg.KubeClient, pod, containerStatus.Name, false)
+			customDetails := addOverrideCustomDetails(getContainerCustomDetails(&containerStatus, containerType), overrides)
+			alertKey := getPodAlertKey(cfg, pod, labels, containerStatus.Name, containerStatus.State.Waiting.Reason)
+			alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, settings.Waiting.Priority, containerLabels, links, podLogs, customDetails)
+			return true
 		}
 
-		if cfg.Alarms.Pods.Restarts.Enabled && containerStatus.RestartCount >= cfg.Alarms.Pods.Restarts.Threshold {
-			summary := fmt.Sprintf("Pod %s/%s restarts threshold reached: %d", pod.GetNamespace(), pod.GetName(), containerStatus.RestartCount)
-			details := getPodDetailsWithStatus(cfg.KubeClient, pod, &containerStatus)
+		if settings.Restarts.Enabled && restarts >= settings.Restarts.Threshold {
+			summary := fmt.Sprintf("%s restarts threshold reached: %d", summaryPrefix, restarts)
+			if settings.Restarts.Mode == RestartsModeRate {
+				summary = fmt.Sprintf("%s restarts threshold reached: %d within %s", summaryPrefix, restarts, settings.Restarts.Window)
+			}
+			details := getContainerDetailsWithStatus(cfg.KubeClient, pod, &containerStatus, containerType)
 			links := getPodLinks(cfg, pod)
-			podLogs := getPodLogs(cfg.KubeClient, pod, containerStatus.Name)
-			customDetails := getPodCustomDetails(&containerStatus)
-			alert.CreateEvent(cfg, podKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.Restarts.Priority, labels, links, podLogs, customDetails)
-			break
+			podLogs := getPodLogs(cfg.KubeClient, pod, containerStatus.Name, false)
+			customDetails := addOverrideCustomDetails(getContainerCustomDetails(&containerStatus, containerType), overrides)
+			if customDetails != nil && settings.Restarts.Mode == RestartsModeRate {
+				customDetails["restarts_within_window"] = restarts
+				customDetails["restarts_window"] = settings.Restarts.Window
+			}
+			alertKey := getPodAlertKey(cfg, pod, labels, containerStatus.Name, "Restarts")
+			alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, settings.Restarts.Priority, containerLabels, links, podLogs, customDetails)
+			return true
 		}
 	}
+
+	return false
 }
 
 func analyzePodResources(pod *api.Pod, cfg *config.Config) error {
@@ -302,6 +413,9 @@ func analyzePodResources(pod *api.Pod, cfg *config.Config) error {
 		if !ok {
 			memoryUsage = 0
 		}
+		if memoryUsage > 0 {
+			setContainerMemoryUsage(pod, container.Name, memoryUsage)
+		}
 
 		if cfg.Alarms.Pods.Resources.CPU.Enabled && cpuUsage > 0 && container.Resources.Limits.Cpu() != nil {
 			cpuLimitDec := container.Resources.Limits.Cpu().AsDec().String()
@@ -317,12 +431,15 @@ func analyzePodResources(pod *api.Pod, cfg *config.Config) error {
 					Float64("limit", cpuLimit).
 					Float64("usage", cpuUsage).
 					Msg("Checking CPU limit")
-				if cpuUsage >= (float64(cfg.Alarms.Pods.Resources.CPU.Threshold) * (cpuLimit / 100)) {
-					healthy = false
-					summary := fmt.Sprintf("Pod %s/%s CPU limit reached > %d%%", pod.GetNamespace(), pod.GetName(), cfg.Alarms.Pods.Resources.CPU.Threshold)
-					details := getPodDetailsWithUsageLimit(cfg.KubeClient, pod, fmt.Sprintf("%.3f CPU", cpuUsage), fmt.Sprintf("%.3f CPU", cpuLimit))
-					links := getPodLinks(cfg, pod)
-					alert.CreateEvent(cfg, podKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.Resources.CPU.Priority, labels, links, nil, nil)
+				resourceHealthy, alarm := getResourceUsageState(getPodResourceStateKey(pod, container.Name, "cpu-limit"), cpuUsage*100/cpuLimit, cfg.Alarms.Pods.Resources.CPU.Threshold, cfg.Alarms.Pods.Resources)
+				healthy = healthy && resourceHealthy
+				if alarm {
+					if podCfg, _, enabled := getPodAlarmSettings(pod, labels, cfg); enabled {
+						summary := fmt.Sprintf("Pod %s/%s CPU limit reached > %d%%", pod.GetNamespace(), pod.GetName(), cfg.Alarms.Pods.Resources.CPU.Threshold)
+						details := getPodDetailsWithUsageLimit(cfg.KubeClient, pod, fmt.Sprintf("%.3f CPU", cpuUsage), fmt.Sprintf("%.3f CPU", cpuLimit))
+						links := getPodLinks(cfg, pod)
+						alert.CreateEvent(cfg, podKey, summary, details, ilert.EventTypes.Alert, podCfg.Alarms.Pods.Resources.CPU.Priority, labels, links, nil, nil)
+					}
 				}
 			}
 		}
@@ -337,15 +454,26 @@ func analyzePodResources(pod *api.Pod, cfg *config.Config) error {
 					Int64("limit", memoryLimit).
 					Int64("usage", memoryUsage).
 					Msg("Checking memory limit")
-				if memoryUsage >= (int64(cfg.Alarms.Pods.Resources.Memory.Threshold) * (memoryLimit / 100)) {
-					healthy = false
-					summary := fmt.Sprintf("Pod %s/%s memory limit reached > %d%%", pod.GetNamespace(), pod.GetName(), cfg.Alarms.Pods.Resources.Memory.Threshold)
-					details := getPodDetailsWithUsageLimit(cfg.KubeClient, pod, humanize.Bytes(uint64(memoryUsage)), humanize.Bytes(uint64(memoryLimit)))
-					links := getPodLinks(cfg, pod)
-					alert.CreateEvent(cfg, podKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.Resources.Memory.Priority, labels, links, nil, nil)
+				resourceHealthy, alarm := getResourceUsageState(getPodResourceStateKey(pod, container.Name, "memory-limit"), float64(memoryUsage)*100/float64(memoryLimit), cfg.Alarms.Pods.Resources.Memory.Threshold, cfg.Alarms.Pods.Resources)
+				healthy = healthy && resourceHealthy
+				if alarm {
+					if podCfg, _, enabled := getPodAlarmSettings(pod, labels, cfg); enabled {
+						summary := fmt.Sprintf("Pod %s/%s memory limit reached > %d%%", pod.GetNamespace(), pod.GetName(), cfg.Alarms.Pods.Resources.Memory.Threshold)
+						details := getPodDetailsWithUsageLimit(cfg.KubeClient, pod, humanize.Bytes(uint64(memoryUsage)), humanize.Bytes(uint64(memoryLimit)))
+						links := getPodLinks(cfg, pod)
+						alert.CreateEvent(cfg, podKey, summary, details, ilert.EventTypes.Alert, podCfg.Alarms.Pods.Resources.Memory.Priority, labels, links, nil, nil)
+					}
 				}
 			}
 		}
+
+		if cfg.Alarms.Pods.Resources.Prediction.Enabled && memoryUsage > 0 {
+			analyzePodMemoryPrediction(pod, &container, memoryUsage, labels, cfg)
+		}
+
+		if cfg.Alarms.Pods.Resources.Requests.Enabled && !analyzeContainerRequests(pod, &container, cpuUsage, memoryUsage, labels, cfg) {
+			healthy = false
+		}
 	}
 	if healthy && cfg.Alarms.Pods.SendResolveEvents {
 		alert.CreateEvent(cfg, podKey, fmt.Sprintf("Pod %s/%s recovered", pod.GetNamespace(), pod.GetName()), "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
@@ -354,20 +482,24 @@ func analyzePodResources(pod *api.Pod, cfg *config.Config) error {
 	return nil
 }
 
-func getEventLabelsFromPod(pod *api.Pod, clientset *kubernetes.Clientset) map[string]string {
-	podNamespace := pod.GetNamespace()
-	podName := pod.GetName()
-
-	labels := map[string]string{
-		"namespace":       podNamespace,
-		"podName":         podName,
+// getEventLabelsFromDeletedPod returns the pod labels without the workload, it can not be looked up for a deleted pod
+func getEventLabelsFromDeletedPod(pod *api.Pod) map[string]string {
+	return alert.WithObjectLabels(map[string]string{
+		"namespace":       pod.GetNamespace(),
+		"podName":         pod.GetName(),
 		"resourceVersion": pod.GetResourceVersion(),
 		"node":            pod.Spec.NodeName,
 		"app":             getLabel(pod, "app"),
 		"stage":           getLabel(pod, "stage"),
 		"version":         getLabel(pod, "version"),
-	}
+	}, pod.GetLabels())
+}
+
+func getEventLabelsFromPod(pod *api.Pod, clientset *kubernetes.Clientset) map[string]string {
+	podNamespace := pod.GetNamespace()
+	podName := pod.GetName()
 
+	labels := getEventLabelsFromDeletedPod(pod)
 	workload, err, _ := commander.FindWorkloadByPodName(clientset, podNamespace, podName)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to find workload by pod name")
@@ -39,27 +39,29 @@ func checkPods(cfg *config.Config) {
 	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
 		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
 		if pressureLevel == "critical" || pressureLevel == "emergency" {
-			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping pod resource check due to memory pressure")
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping pod check due to memory pressure")
 			return
 		}
 	}
 
-	if !cfg.Alarms.Pods.Resources.Enabled {
+	if !cfg.Alarms.Pods.Resources.Enabled && !cfg.Alarms.Pods.Pending.Enabled && !cfg.Alarms.Pods.Terminating.Enabled {
 		return
 	}
 
 	informer := GetPodInformer()
 	if informer == nil {
-		log.Warn().Msg("Pod informer not available, skipping resource check")
+		log.Warn().Msg("Pod informer not available, skipping pod check")
 		return
 	}
 
 	if !cache.WaitForCacheSync(nil, informer.HasSynced) {
-		log.Warn().Msg("Pod informer cache not synced, skipping resource check")
+		log.Warn().Msg("Pod informer cache not synced, skipping pod check")
 		return
 	}
 
-	log.Debug().Msg("Running pods resource check")
+	log.Debug().Msg("Running pods check")
+
+	metricsAvailable := cfg.Alarms.Pods.Resources.Enabled && isMetricsAPIAvailable(cfg)
 
 	pods := informer.GetStore().List()
 	for _, obj := range pods {
@@ -68,7 +70,14 @@ func checkPods(cfg *config.Config) {
 			log.Debug().Msg("Failed to convert object to pod, skipping")
 			continue
 		}
-		analyzePodResources(pod, cfg)
+		if !isInScope(pod, cfg) {
+			continue
+		}
+		analyzePodPending(pod, cfg)
+		analyzePodTerminating(pod, cfg)
+		if metricsAvailable {
+			analyzePodResources(pod, cfg)
+		}
 	}
 }
 
@@ -0,0 +1,125 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/utils"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func getPodEvictionKey(pod *api.Pod) string {
+	return fmt.Sprintf("%s-evicted", getPodKey(pod))
+}
+
+// getPodEvictionReason returns the pod level eviction, preemption or admission failure reason and message
+func getPodEvictionReason(pod *api.Pod) (string, string) {
+	if utils.StringContains(podEvictedReasons, pod.Status.Reason) || strings.HasPrefix(pod.Status.Reason, OutOfResource) {
+		return pod.Status.Reason, pod.Status.Message
+	}
+
+	// Preemption and taint based evictions are announced by the disruption target condition before the pod is gone
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == api.DisruptionTarget && condition.Status == api.ConditionTrue &&
+			(condition.Reason == "PreemptionByScheduler" || condition.Reason == "TerminationByKubelet" || condition.Reason == "DeletionByTaintManager") {
+			reason := Evicted
+			if condition.Reason == "PreemptionByScheduler" {
+				reason = Preempting
+			}
+			return reason, condition.Message
+		}
+	}
+	return "", ""
+}
+
+// getPodEvictionTime returns when the pod was evicted, the disruption target condition or the latest pod condition transition
+func getPodEvictionTime(pod *api.Pod) time.Time {
+	evictedAt := time.Time{}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == api.DisruptionTarget && condition.Status == api.ConditionTrue {
+			return condition.LastTransitionTime.Time
+		}
+		if condition.LastTransitionTime.After(evictedAt) {
+			evictedAt = condition.LastTransitionTime.Time
+		}
+	}
+	if evictedAt.IsZero() {
+		return time.Now()
+	}
+	return evictedAt
+}
+
+// getNodePressureConditions returns the pressure conditions the node reported at the eviction time, a condition
+// that is still true started before and a condition that is false ended after the eviction
+func getNodePressureConditions(kubeClient *kubernetes.Clientset, nodeName string, evictedAt time.Time) []string {
+	if nodeName == "" {
+		return nil
+	}
+
+	node, err := getNode(kubeClient, nodeName)
+	if err != nil {
+		log.Debug().Err(err).Str("node", nodeName).Msg("Failed to get pod node")
+		return nil
+	}
+
+	pressure := make([]string, 0)
+	for _, condition := range node.Status.Conditions {
+		if !strings.HasSuffix(string(condition.Type), "Pressure") || condition.LastTransitionTime.IsZero() {
+			continue
+		}
+		transition := condition.LastTransitionTime.Time
+		if (condition.Status == api.ConditionTrue && !transition.After(evictedAt)) ||
+			(condition.Status == api.ConditionFalse && transition.After(evictedAt)) {
+			pressure = append(pressure, string(condition.Type))
+		}
+	}
+	return pressure
+}
+
+// analyzePodEviction creates an alert for evicted, preempted or rejected pods and reports whether an alert was created
+func analyzePodEviction(pod *api.Pod, cfg *config.Config, labels map[string]string, overrides alarmOverrides) bool {
+	if !cfg.Alarms.Pods.Evictions.Enabled {
+		return false
+	}
+
+	reason, message := getPodEvictionReason(pod)
+	if reason == "" {
+		return false
+	}
+
+	nodePressure := getNodePressureConditions(cfg.KubeClient, pod.Spec.NodeName, getPodEvictionTime(pod))
+	podLabels := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		podLabels[k] = v
+	}
+	podLabels["reason"] = reason
+
+	summary := fmt.Sprintf("Pod %s/%s %s", pod.GetNamespace(), pod.GetName(), reason)
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nNode: %s\nPhase: %s\nReason: %s\nMessage: %s",
+		pod.GetName(),
+		pod.GetNamespace(),
+		pod.Spec.NodeName,
+		pod.Status.Phase,
+		reason,
+		message)
+	if len(nodePressure) > 0 {
+		details += fmt.Sprintf("\nNode pressure: %s", strings.Join(nodePressure, ", "))
+	}
+
+	customDetails := addOverrideCustomDetails(map[string]interface{}{
+		"phase":         string(pod.Status.Phase),
+		"reason":        reason,
+		"message":       message,
+		"node":          pod.Spec.NodeName,
+		"node_pressure": nodePressure,
+	}, overrides)
+	addOpenAlert(getPodKey(pod), getPodEvictionKey(pod))
+	alert.CreateEvent(cfg, getPodEvictionKey(pod), summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.Evictions.Priority, podLabels, getPodLinks(cfg, pod), nil, customDetails)
+	return true
+}
@@ -1,11 +1,12 @@
 package watcher
 
 import (
-	"time"
+	"context"
 
 	"github.com/rs/zerolog/log"
 	api "k8s.io/api/core/v1"
-	"k8s.io/client-go/informers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/iLert/ilert-kube-agent/pkg/config"
@@ -18,21 +19,24 @@ var (
 )
 
 func startPodInformer(cfg *config.Config) {
-	if sharedFactory == nil {
-		sharedFactory = informers.NewSharedInformerFactory(cfg.KubeClient, 15*time.Minute)
-	}
-
-	podInformer = sharedFactory.Core().V1().Pods().Informer()
+	podInformer = podFactory.Core().V1().Pods().Informer()
 	podInformerStopper = make(chan struct{})
 	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
 			pod := newObj.(*api.Pod)
+			if !isInScope(pod, cfg) {
+				return
+			}
 			log.Debug().Interface("pod", pod.GetName()).Msg("Update Pod")
 			analyzePodStatus(pod, cfg)
 		},
 		DeleteFunc: func(obj interface{}) {
-			pod := obj.(*api.Pod)
+			pod, ok := getDeletedObject(obj).(*api.Pod)
+			if !ok || !isInScope(pod, cfg) {
+				return
+			}
 			log.Debug().Interface("pod", pod.Name).Msg("Delete Pod")
+			analyzePodDeleted(pod, cfg)
 		},
 	})
 
@@ -53,3 +57,42 @@ func stopPodInformer() {
 func GetPodInformer() cache.SharedInformer {
 	return podInformer
 }
+
+// getNamespacePods returns the pods of the namespace from the pod informer, false if the informer is not running or not synced
+func getNamespacePods(namespace string) ([]*api.Pod, bool) {
+	informer, ok := podInformer.(cache.SharedIndexInformer)
+	if !ok || !informer.HasSynced() {
+		return nil, false
+	}
+
+	objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		log.Debug().Err(err).Str("namespace", namespace).Msg("Failed to get namespace pods from informer")
+		return nil, false
+	}
+
+	pods := make([]*api.Pod, 0, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*api.Pod); ok {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, true
+}
+
+// listNamespacePods returns the pods of the namespace, they are only listed from the api if the pod informer is not running e.g. in run once mode
+func listNamespacePods(kubeClient *kubernetes.Clientset, namespace string) ([]*api.Pod, error) {
+	if pods, ok := getNamespacePods(namespace); ok {
+		return pods, nil
+	}
+
+	podList, err := kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*api.Pod, 0, len(podList.Items))
+	for i := range podList.Items {
+		pods = append(pods, &podList.Items[i])
+	}
+	return pods, nil
+}
@@ -0,0 +1,144 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iLert/ilert-kube-agent/pkg/commander"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	api "k8s.io/api/core/v1"
+)
+
+// Alert key strategies of the pod status alarms
+const (
+	KeyStrategyPod            = "pod"
+	KeyStrategyContainer      = "container"
+	KeyStrategyWorkload       = "workload"
+	KeyStrategyWorkloadReason = "workloadReason"
+)
+
+// getWorkloadFromLabels returns the workload resolved by getEventLabelsFromPod, false if the pod is not owned by a workload
+func getWorkloadFromLabels(labels map[string]string) (commander.WorkloadInfo, bool) {
+	workloadType := commander.WorkloadType(labels["workloadType"])
+	switch workloadType {
+	case commander.WorkloadTypeDeployment, commander.WorkloadTypeStatefulSet, commander.WorkloadTypeDaemonSet:
+	default:
+		return commander.WorkloadInfo{}, false
+	}
+
+	name := labels[string(workloadType)]
+	if name == "" {
+		return commander.WorkloadInfo{}, false
+	}
+	return commander.WorkloadInfo{Type: workloadType, Name: name}, true
+}
+
+// getWorkloadPodsKey returns the alert key shared by all replicas of the workload, it must not collide with the workload alarm key
+func getWorkloadPodsKey(namespace string, workload commander.WorkloadInfo) string {
+	return fmt.Sprintf("%s-pods", getWorkloadKey(namespace, workload))
+}
+
+// getPodContainersKey returns the object key the open container alerts of the pod are tracked on, they are resolved
+// once the containers recovered while the other pod alerts stay open until they recover on their own or the pod is deleted
+func getPodContainersKey(pod *api.Pod) string {
+	return fmt.Sprintf("%s/containers", getPodKey(pod))
+}
+
+// getPodAlertKey returns the alert key of a terminate, waiting or restarts alarm for the configured key strategy and tracks it as open,
+// the workload strategies fall back to the pod key for pods without a workload. The other pod alarms keep their per pod keys,
+// they describe the lifecycle of a single pod e.g. its scheduling, eviction or deletion
+func getPodAlertKey(cfg *config.Config, pod *api.Pod, labels map[string]string, containerName string, reason string) string {
+	podKey := getPodKey(pod)
+
+	switch cfg.Alarms.Pods.KeyStrategy {
+	case KeyStrategyContainer:
+		alertKey := fmt.Sprintf("%s-%s", podKey, containerName)
+		addOpenAlert(getPodContainersKey(pod), alertKey)
+		return alertKey
+	case KeyStrategyWorkload, KeyStrategyWorkloadReason:
+		workload, ok := getWorkloadFromLabels(labels)
+		if !ok {
+			break
+		}
+
+		// The alert outlives the replicas, it is tracked on the workload and resolved once none of its replicas is unhealthy
+		workloadKey := getWorkloadKey(pod.GetNamespace(), workload)
+		alertKey := getWorkloadPodsKey(pod.GetNamespace(), workload)
+		if cfg.Alarms.Pods.KeyStrategy == KeyStrategyWorkloadReason {
+			alertKey = fmt.Sprintf("%s-%s", alertKey, reason)
+		}
+		addOpenAlert(workloadKey, alertKey)
+		addOpenAlert(getWorkloadReplicasKey(workloadKey), podKey)
+		addOpenAlert(getPodWorkloadsKey(pod), workloadKey)
+		return alertKey
+	}
+
+	addOpenAlert(getPodContainersKey(pod), podKey)
+	return podKey
+}
+
+// getWorkloadReplicasKey returns the object key the unhealthy replicas of the workload are tracked on
+func getWorkloadReplicasKey(workloadKey string) string {
+	return fmt.Sprintf("%s/replicas", workloadKey)
+}
+
+// getPodWorkloadsKey returns the object key the workloads with an alert of the pod are tracked on, the workload
+// of a deleted pod can not be looked up anymore
+func getPodWorkloadsKey(pod *api.Pod) string {
+	return fmt.Sprintf("%s/workloads", getPodKey(pod))
+}
+
+func getEventLabelsFromWorkloadKey(workloadKey string) map[string]string {
+	parts := strings.SplitN(workloadKey, "/", 3)
+	if len(parts) != 3 {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"namespace":    parts[0],
+		"workloadType": parts[1],
+		parts[1]:       parts[2],
+	}
+}
+
+// isPodDeleted reports whether the pod is gone from the pod informer, it is never the case without informer e.g. in run once mode
+func isPodDeleted(podKey string) bool {
+	informer := GetPodInformer()
+	if informer == nil {
+		return false
+	}
+	_, exists, err := informer.GetStore().GetByKey(podKey)
+	return err == nil && !exists
+}
+
+// releaseWorkloadPodAlerts removes the healthy or deleted pod from the unhealthy replicas of its workloads and
+// resolves the collapsed alerts of a workload once none of its replicas is unhealthy anymore
+func releaseWorkloadPodAlerts(pod *api.Pod, cfg *config.Config) {
+	workloadKeys := takeOpenAlerts(getPodWorkloadsKey(pod))
+	if len(workloadKeys) == 0 {
+		return
+	}
+
+	for _, workloadKey := range workloadKeys {
+		replicasKey := getWorkloadReplicasKey(workloadKey)
+		removeOpenAlert(replicasKey, getPodKey(pod))
+
+		// Replicas deleted while the agent was not running would keep the alert open forever
+		unhealthy := false
+		for _, podKey := range getOpenAlerts(replicasKey) {
+			if isPodDeleted(podKey) {
+				removeOpenAlert(replicasKey, podKey)
+				continue
+			}
+			unhealthy = true
+		}
+		if unhealthy {
+			continue
+		}
+
+		// The resolve is sent with the labels of the alert, these labels are only the fallback
+		labels := getEventLabelsFromWorkloadKey(workloadKey)
+		workloadType := commander.WorkloadType(labels["workloadType"])
+		summary := fmt.Sprintf("%s %s/%s pods recovered", getWorkloadKind(workloadType), labels["namespace"], labels[string(workloadType)])
+		resolveOpenAlerts(cfg, workloadKey, summary, labels, cfg.Alarms.Pods.SendResolveEvents)
+	}
+}
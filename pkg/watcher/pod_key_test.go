@@ -0,0 +1,94 @@
+package watcher
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+func TestGetPodAlertKey(t *testing.T) {
+	pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api-7d9f-x2x4p"}}
+	deploymentLabels := map[string]string{"namespace": "default", "workloadType": "deployment", "deployment": "api"}
+	podLabels := map[string]string{"namespace": "default"}
+
+	tests := []struct {
+		name            string
+		keyStrategy     string
+		labels          map[string]string
+		want            string
+		wantOpenObject  string
+		wantOpenAlerts  []string
+		wantReplicasKey string
+	}{
+		{
+			name:           "pod",
+			keyStrategy:    KeyStrategyPod,
+			labels:         deploymentLabels,
+			want:           "default/api-7d9f-x2x4p",
+			wantOpenObject: "default/api-7d9f-x2x4p/containers",
+			wantOpenAlerts: []string{"default/api-7d9f-x2x4p"},
+		},
+		{
+			name:           "container",
+			keyStrategy:    KeyStrategyContainer,
+			labels:         deploymentLabels,
+			want:           "default/api-7d9f-x2x4p-app",
+			wantOpenObject: "default/api-7d9f-x2x4p/containers",
+			wantOpenAlerts: []string{"default/api-7d9f-x2x4p-app"},
+		},
+		{
+			name:            "workload",
+			keyStrategy:     KeyStrategyWorkload,
+			labels:          deploymentLabels,
+			want:            "default/deployment/api-pods",
+			wantOpenObject:  "default/deployment/api",
+			wantOpenAlerts:  []string{"default/deployment/api-pods"},
+			wantReplicasKey: "default/deployment/api/replicas",
+		},
+		{
+			name:            "workload reason",
+			keyStrategy:     KeyStrategyWorkloadReason,
+			labels:          deploymentLabels,
+			want:            "default/deployment/api-pods-CrashLoopBackOff",
+			wantOpenObject:  "default/deployment/api",
+			wantOpenAlerts:  []string{"default/deployment/api-pods-CrashLoopBackOff"},
+			wantReplicasKey: "default/deployment/api/replicas",
+		},
+		{
+			name:           "workload without workload falls back to the pod",
+			keyStrategy:    KeyStrategyWorkload,
+			labels:         podLabels,
+			want:           "default/api-7d9f-x2x4p",
+			wantOpenObject: "default/api-7d9f-x2x4p/containers",
+			wantOpenAlerts: []string{"default/api-7d9f-x2x4p"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache.Cache.Init(100)
+			cfg := &config.Config{}
+			cfg.Alarms.Pods.KeyStrategy = test.keyStrategy
+
+			if got := getPodAlertKey(cfg, pod, test.labels, "app", "CrashLoopBackOff"); got != test.want {
+				t.Errorf("getPodAlertKey() = %q, want %q", got, test.want)
+			}
+			if got := getOpenAlerts(test.wantOpenObject); !reflect.DeepEqual(got, test.wantOpenAlerts) {
+				t.Errorf("open alerts of %q = %v, want %v", test.wantOpenObject, got, test.wantOpenAlerts)
+			}
+			if test.wantReplicasKey != "" {
+				if got := getOpenAlerts(test.wantReplicasKey); !reflect.DeepEqual(got, []string{"default/api-7d9f-x2x4p"}) {
+					t.Errorf("replicas = %v, want the pod", got)
+				}
+				if got := getOpenAlerts(getPodWorkloadsKey(pod)); !reflect.DeepEqual(got, []string{"default/deployment/api"}) {
+					t.Errorf("pod workloads = %v, want the deployment", got)
+				}
+			}
+		})
+	}
+}
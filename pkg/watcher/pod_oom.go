@@ -0,0 +1,154 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	ccache "github.com/karlseguin/ccache/v2"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getContainerOOMKey(pod *api.Pod, containerName string) string {
+	return fmt.Sprintf("%s-%s-oomkilled", getPodKey(pod), containerName)
+}
+
+// memoryUsageTTL is how long the last observed memory usage is kept for OOM alerts
+const memoryUsageTTL = time.Hour
+
+// memoryUsageCache keeps the last observed memory usage of the containers in a separate bounded cache, it is written
+// for every container on every check and must not evict the alarm state
+var memoryUsageCache = ccache.New(ccache.Configure().MaxSize(20000).ItemsToPrune(2000))
+
+func getContainerMemoryUsageKey(pod *api.Pod, containerName string) string {
+	return fmt.Sprintf("%s-%s", getPodKey(pod), containerName)
+}
+
+// setContainerMemoryUsage stores the last observed memory usage so it is still known after the container was killed
+func setContainerMemoryUsage(pod *api.Pod, containerName string, usage int64) {
+	memoryUsageCache.Set(getContainerMemoryUsageKey(pod, containerName), usage, memoryUsageTTL)
+}
+
+// deleteContainerMemoryUsages forgets the memory usage of all containers of the deleted pod
+func deleteContainerMemoryUsages(pod *api.Pod) {
+	for _, container := range pod.Spec.Containers {
+		memoryUsageCache.Delete(getContainerMemoryUsageKey(pod, container.Name))
+	}
+}
+
+// getContainerMemoryUsage returns the last observed memory usage, falls back to the current usage from the metrics api
+func getContainerMemoryUsage(pod *api.Pod, containerName string, cfg *config.Config) int64 {
+	if item := memoryUsageCache.Get(getContainerMemoryUsageKey(pod, containerName)); item != nil && !item.Expired() {
+		if usage, ok := item.Value().(int64); ok {
+			return usage
+		}
+	}
+
+	if cfg.MetricsClient == nil {
+		return 0
+	}
+	podMetrics, err := cfg.MetricsClient.MetricsV1beta1().PodMetricses(pod.GetNamespace()).Get(context.TODO(), pod.GetName(), metav1.GetOptions{})
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to get pod metrics")
+		return 0
+	}
+	for _, container := range podMetrics.Containers {
+		if container.Name == containerName {
+			usage, ok := container.Usage.Memory().AsInt64()
+			if ok {
+				return usage
+			}
+		}
+	}
+	return 0
+}
+
+func getContainerMemoryLimit(pod *api.Pod, containerName string) int64 {
+	containers := append(append([]api.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		if container.Name != containerName {
+			continue
+		}
+		if limit, ok := container.Resources.Limits.Memory().AsInt64(); ok {
+			return limit
+		}
+	}
+	return 0
+}
+
+// oomKilledResyncPeriod is the pod informer resync period, every kill is observed at least once within it
+const oomKilledResyncPeriod = 15 * time.Minute
+
+// getOOMKilledMaxAge returns the age up to which a kill without dedup state is still alerted
+func getOOMKilledMaxAge(cfg *config.Config) time.Duration {
+	if checkInterval := getDuration(cfg.Settings.CheckInterval); checkInterval > oomKilledResyncPeriod {
+		return checkInterval
+	}
+	return oomKilledResyncPeriod
+}
+
+// analyzeContainerOOMKilled creates an alert once per OOM kill found in the last termination state and reports whether an alert was created
+func analyzeContainerOOMKilled(pod *api.Pod, cfg *config.Config, containerStatus *api.ContainerStatus, containerType string, labels map[string]string, overrides alarmOverrides) bool {
+	terminated := containerStatus.LastTerminationState.Terminated
+	if !cfg.Alarms.Pods.OOMKilled.Enabled || containerStatus.State.Terminated != nil || terminated == nil || terminated.Reason != OOMKilled {
+		return false
+	}
+
+	// The last state stays the same until the next termination, only the first observation of each kill is alerted
+	alertKey := getContainerOOMKey(pod, containerStatus.Name)
+	stateKey := getProblemStateKey(alertKey)
+	finishedAt := terminated.FinishedAt.Format(time.RFC3339)
+	value, err := cache.Cache.State.GetItem(stateKey)
+	if err == nil && value == finishedAt {
+		return false
+	}
+	cache.Cache.State.SetItem(stateKey, finishedAt, problemStateTTL)
+
+	// Without dedup state the agent restarted or runs once, an older kill was already seen by a previous run
+	if (err != nil || value == "") && time.Since(terminated.FinishedAt.Time) > getOOMKilledMaxAge(cfg) {
+		log.Debug().
+			Str("pod", pod.GetName()).
+			Str("namespace", pod.GetNamespace()).
+			Str("container", containerStatus.Name).
+			Msg("Skipping alert for OOM kill observed before the agent started")
+		return false
+	}
+
+	memoryLimit := getContainerMemoryLimit(pod, containerStatus.Name)
+	memoryUsage := getContainerMemoryUsage(pod, containerStatus.Name, cfg)
+
+	summary := fmt.Sprintf("%s OOMKilled at %s", getContainerSummaryPrefix(pod, containerType, containerStatus.Name), finishedAt)
+	details := getContainerDetailsWithStatus(cfg.KubeClient, pod, containerStatus, containerType)
+	details += fmt.Sprintf("\nExit code: %d\nFinished at: %s\nRestart count: %d", terminated.ExitCode, finishedAt, containerStatus.RestartCount)
+	customDetails := map[string]interface{}{
+		"container":      containerStatus.Name,
+		"container_type": containerType,
+		"reason":         terminated.Reason,
+		"exit_code":      terminated.ExitCode,
+		"started_at":     terminated.StartedAt,
+		"finished_at":    terminated.FinishedAt,
+		"restart_count":  containerStatus.RestartCount,
+	}
+	if memoryLimit > 0 {
+		details += fmt.Sprintf("\nMemory limit: %s", humanize.Bytes(uint64(memoryLimit)))
+		customDetails["memory_limit"] = memoryLimit
+	}
+	if memoryUsage > 0 {
+		details += fmt.Sprintf("\nLast observed memory usage: %s", humanize.Bytes(uint64(memoryUsage)))
+		customDetails["memory_usage"] = memoryUsage
+	}
+
+	customDetails = addOverrideCustomDetails(customDetails, overrides)
+	links := getPodLinks(cfg, pod)
+	podLogs := getPodLogs(cfg.KubeClient, pod, containerStatus.Name, true)
+	addOpenAlert(getPodKey(pod), alertKey)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.OOMKilled.Priority, labels, links, podLogs, customDetails)
+	return true
+}
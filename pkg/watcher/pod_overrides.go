@@ -0,0 +1,252 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iLert/ilert-kube-agent/pkg/commander"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// These are the annotations overriding the pod alarm config, the pod annotations take precedence over
+// the owning workload annotations and the workload annotations over the namespace annotations
+const (
+	AnnotationDisabled          = "ilert.com/disabled"
+	AnnotationPriority          = "ilert.com/priority"
+	AnnotationRestartsThreshold = "ilert.com/restarts-threshold"
+	AnnotationExcludedReasons   = "ilert.com/excluded-reasons"
+)
+
+var overrideAnnotations = []string{AnnotationDisabled, AnnotationPriority, AnnotationRestartsThreshold, AnnotationExcludedReasons}
+
+// These are the sources of the alarm overrides
+const (
+	OverrideSourcePod       = "pod"
+	OverrideSourceWorkload  = "workload"
+	OverrideSourceNamespace = "namespace"
+)
+
+// overrideCacheTTL bounds how long annotation changes of workloads and namespaces take to apply
+const overrideCacheTTL = time.Minute
+
+type annotationsCacheItem struct {
+	annotations map[string]string
+	expires     time.Time
+}
+
+// annotationsCache keeps the workload and namespace annotations in memory, lookup failures are cached as well
+var annotationsCache = struct {
+	sync.Mutex
+	items map[string]annotationsCacheItem
+}{items: make(map[string]annotationsCacheItem)}
+
+func getCachedAnnotations(key string, load func() map[string]string) map[string]string {
+	annotationsCache.Lock()
+	item, ok := annotationsCache.items[key]
+	annotationsCache.Unlock()
+	if ok && time.Now().Before(item.expires) {
+		return item.annotations
+	}
+
+	annotations := load()
+
+	annotationsCache.Lock()
+	now := time.Now()
+	for key, item := range annotationsCache.items {
+		if now.After(item.expires) {
+			delete(annotationsCache.items, key)
+		}
+	}
+	annotationsCache.items[key] = annotationsCacheItem{annotations: annotations, expires: now.Add(overrideCacheTTL)}
+	annotationsCache.Unlock()
+	return annotations
+}
+
+type alarmOverride struct {
+	Value  string
+	Source string
+}
+
+// alarmOverrides are the effective override annotations of a pod by annotation name
+type alarmOverrides map[string]alarmOverride
+
+func getWorkloadAnnotations(kubeClient *kubernetes.Clientset, namespace string, labels map[string]string) map[string]string {
+	workloadType := commander.WorkloadType(labels["workloadType"])
+	workloadName := labels[string(workloadType)]
+	if workloadName == "" {
+		return nil
+	}
+
+	return getCachedAnnotations(fmt.Sprintf("workload:%s/%s/%s", namespace, workloadType, workloadName), func() map[string]string {
+		var obj metav1.Object
+		var err error
+		switch workloadType {
+		case commander.WorkloadTypeDeployment:
+			obj, err = kubeClient.AppsV1().Deployments(namespace).Get(context.TODO(), workloadName, metav1.GetOptions{})
+		case commander.WorkloadTypeStatefulSet:
+			obj, err = kubeClient.AppsV1().StatefulSets(namespace).Get(context.TODO(), workloadName, metav1.GetOptions{})
+		case commander.WorkloadTypeDaemonSet:
+			obj, err = kubeClient.AppsV1().DaemonSets(namespace).Get(context.TODO(), workloadName, metav1.GetOptions{})
+		default:
+			return nil
+		}
+		if err != nil {
+			log.Debug().Err(err).Str("workload", workloadName).Str("namespace", namespace).Msg("Failed to get pod workload")
+			return nil
+		}
+		return obj.GetAnnotations()
+	})
+}
+
+func getNamespaceAnnotations(kubeClient *kubernetes.Clientset, namespace string) map[string]string {
+	return getCachedAnnotations(fmt.Sprintf("namespace:%s", namespace), func() map[string]string {
+		ns, err := kubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+		if err != nil {
+			log.Debug().Err(err).Str("namespace", namespace).Msg("Failed to get pod namespace")
+			return nil
+		}
+		return ns.GetAnnotations()
+	})
+}
+
+// getPodAlarmOverrides resolves the override annotations of the pod, its workload and its namespace in precedence order
+func getPodAlarmOverrides(pod *api.Pod, labels map[string]string, cfg *config.Config) alarmOverrides {
+	sources := []struct {
+		name        string
+		annotations map[string]string
+	}{
+		{OverrideSourcePod, pod.GetAnnotations()},
+		{OverrideSourceWorkload, getWorkloadAnnotations(cfg.KubeClient, pod.GetNamespace(), labels)},
+		{OverrideSourceNamespace, getNamespaceAnnotations(cfg.KubeClient, pod.GetNamespace())},
+	}
+
+	overrides := make(alarmOverrides)
+	for _, annotation := range overrideAnnotations {
+		for _, source := range sources {
+			if value, ok := source.annotations[annotation]; ok {
+				overrides[annotation] = alarmOverride{Value: strings.TrimSpace(value), Source: source.name}
+				break
+			}
+		}
+	}
+	return overrides
+}
+
+// getPodAlarmSettings resolves the overrides right before a pod alarm and returns the overridden config, false if the pod alarms are disabled
+func getPodAlarmSettings(pod *api.Pod, labels map[string]string, cfg *config.Config) (*config.Config, alarmOverrides, bool) {
+	overrides := getPodAlarmOverrides(pod, labels, cfg)
+	if overrides.isDisabled() {
+		log.Debug().Str("pod", pod.GetName()).Str("namespace", pod.GetNamespace()).Msg("Skipping pod alarms disabled by annotation")
+		return cfg, overrides, false
+	}
+	return getPodAlarmConfig(cfg, overrides), overrides, true
+}
+
+func (overrides alarmOverrides) isDisabled() bool {
+	disabled, err := strconv.ParseBool(overrides[AnnotationDisabled].Value)
+	return err == nil && disabled
+}
+
+func (overrides alarmOverrides) getPriority() string {
+	priority := strings.ToUpper(overrides[AnnotationPriority].Value)
+	if priority != "HIGH" && priority != "LOW" {
+		return ""
+	}
+	return priority
+}
+
+func (overrides alarmOverrides) getRestartsThreshold() int32 {
+	threshold, err := strconv.ParseInt(overrides[AnnotationRestartsThreshold].Value, 10, 32)
+	if err != nil || threshold < 1 {
+		return 0
+	}
+	return int32(threshold)
+}
+
+func (overrides alarmOverrides) getExcludedReasons() []string {
+	value, ok := overrides[AnnotationExcludedReasons]
+	if !ok {
+		return nil
+	}
+	reasons := make([]string, 0)
+	for _, reason := range strings.Split(value.Value, ",") {
+		if reason = strings.TrimSpace(reason); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+func applyContainersOverrides(settings *config.ConfigAlarmsContainers, overrides alarmOverrides) {
+	if priority := overrides.getPriority(); priority != "" {
+		settings.Terminate.Priority = priority
+		settings.Waiting.Priority = priority
+		settings.Restarts.Priority = priority
+	}
+	if threshold := overrides.getRestartsThreshold(); threshold > 0 {
+		settings.Restarts.Threshold = threshold
+	}
+	if reasons := overrides.getExcludedReasons(); reasons != nil {
+		settings.Terminate.ExcludedReasons = reasons
+	}
+}
+
+// getPodAlarmConfig returns a copy of the config with the pod alarm settings overridden
+func getPodAlarmConfig(cfg *config.Config, overrides alarmOverrides) *config.Config {
+	if len(overrides) == 0 {
+		return cfg
+	}
+
+	podCfg := *cfg
+	pods := &podCfg.Alarms.Pods
+	if priority := overrides.getPriority(); priority != "" {
+		pods.Terminate.Priority = priority
+		pods.Waiting.Priority = priority
+		pods.Restarts.Priority = priority
+		pods.Pending.Priority = priority
+		pods.Terminating.Priority = priority
+		pods.Evictions.Priority = priority
+		pods.OOMKilled.Priority = priority
+		pods.Resources.CPU.Priority = priority
+		pods.Resources.Memory.Priority = priority
+		pods.Resources.Requests.Priority = priority
+		pods.Resources.Prediction.Priority = priority
+	}
+	if threshold := overrides.getRestartsThreshold(); threshold > 0 {
+		pods.Restarts.Threshold = threshold
+	}
+	if reasons := overrides.getExcludedReasons(); reasons != nil {
+		pods.Terminate.ExcludedReasons = reasons
+	}
+	applyContainersOverrides(&pods.InitContainers, overrides)
+	applyContainersOverrides(&pods.EphemeralContainers, overrides)
+	return &podCfg
+}
+
+// addOverrideCustomDetails shows the effective override annotations and their source in the alert
+func addOverrideCustomDetails(customDetails map[string]interface{}, overrides alarmOverrides) map[string]interface{} {
+	if len(overrides) == 0 {
+		return customDetails
+	}
+	if customDetails == nil {
+		customDetails = make(map[string]interface{})
+	}
+
+	effective := make(map[string]interface{}, len(overrides))
+	for annotation, override := range overrides {
+		effective[annotation] = map[string]string{
+			"value":  override.Value,
+			"source": override.Source,
+		}
+	}
+	customDetails["overrides"] = effective
+	return customDetails
+}
@@ -0,0 +1,134 @@
+package watcher
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/iLert/ilert-kube-agent/pkg/commander"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+// setCachedAnnotations seeds the annotations cache so the overrides are resolved without api calls
+func setCachedAnnotations(key string, annotations map[string]string) {
+	annotationsCache.Lock()
+	annotationsCache.items[key] = annotationsCacheItem{annotations: annotations, expires: time.Now().Add(time.Hour)}
+	annotationsCache.Unlock()
+}
+
+func TestGetPodAlarmSettings(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Alarms.Pods.Restarts.Priority = "LOW"
+	cfg.Alarms.Pods.Restarts.Threshold = 10
+	cfg.Alarms.Pods.Terminate.Priority = "HIGH"
+	cfg.Alarms.Pods.Terminate.ExcludedReasons = []string{"Completed"}
+
+	tests := []struct {
+		name                string
+		pod                 map[string]string
+		workload            map[string]string
+		namespace           map[string]string
+		wantEnabled         bool
+		wantPriority        string
+		wantThreshold       int32
+		wantExcludedReasons []string
+		wantPrioritySource  string
+	}{
+		{
+			name:                "no annotations",
+			wantEnabled:         true,
+			wantPriority:        "HIGH",
+			wantThreshold:       10,
+			wantExcludedReasons: []string{"Completed"},
+		},
+		{
+			name:                "namespace annotation",
+			namespace:           map[string]string{AnnotationPriority: "low"},
+			wantEnabled:         true,
+			wantPriority:        "LOW",
+			wantThreshold:       10,
+			wantExcludedReasons: []string{"Completed"},
+			wantPrioritySource:  OverrideSourceNamespace,
+		},
+		{
+			name:                "workload annotation over namespace annotation",
+			workload:            map[string]string{AnnotationPriority: "HIGH", AnnotationRestartsThreshold: "3"},
+			namespace:           map[string]string{AnnotationPriority: "LOW", AnnotationRestartsThreshold: "5"},
+			wantEnabled:         true,
+			wantPriority:        "HIGH",
+			wantThreshold:       3,
+			wantExcludedReasons: []string{"Completed"},
+			wantPrioritySource:  OverrideSourceWorkload,
+		},
+		{
+			name:                "pod annotation over workload annotation",
+			pod:                 map[string]string{AnnotationPriority: "LOW", AnnotationExcludedReasons: "Error, OOMKilled"},
+			workload:            map[string]string{AnnotationPriority: "HIGH"},
+			wantEnabled:         true,
+			wantPriority:        "LOW",
+			wantThreshold:       10,
+			wantExcludedReasons: []string{"Error", "OOMKilled"},
+			wantPrioritySource:  OverrideSourcePod,
+		},
+		{
+			name:                "invalid values are ignored",
+			pod:                 map[string]string{AnnotationPriority: "urgent", AnnotationRestartsThreshold: "0"},
+			wantEnabled:         true,
+			wantPriority:        "HIGH",
+			wantThreshold:       10,
+			wantExcludedReasons: []string{"Completed"},
+			wantPrioritySource:  OverrideSourcePod,
+		},
+		{
+			name:      "disabled by namespace",
+			namespace: map[string]string{AnnotationDisabled: "true"},
+		},
+		{
+			name:                "pod enables a disabled namespace",
+			pod:                 map[string]string{AnnotationDisabled: "false"},
+			namespace:           map[string]string{AnnotationDisabled: "true"},
+			wantEnabled:         true,
+			wantPriority:        "HIGH",
+			wantThreshold:       10,
+			wantExcludedReasons: []string{"Completed"},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := fmt.Sprintf("overrides-%d", i)
+			labels := map[string]string{"workloadType": string(commander.WorkloadTypeDeployment), string(commander.WorkloadTypeDeployment): "api"}
+			setCachedAnnotations(fmt.Sprintf("workload:%s/%s/api", namespace, commander.WorkloadTypeDeployment), test.workload)
+			setCachedAnnotations(fmt.Sprintf("namespace:%s", namespace), test.namespace)
+			pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "api-0", Annotations: test.pod}}
+
+			podCfg, overrides, enabled := getPodAlarmSettings(pod, labels, cfg)
+			if enabled != test.wantEnabled {
+				t.Fatalf("getPodAlarmSettings() enabled = %v, want %v", enabled, test.wantEnabled)
+			}
+			if !enabled {
+				return
+			}
+			if got := podCfg.Alarms.Pods.Terminate.Priority; got != test.wantPriority {
+				t.Errorf("priority = %q, want %q", got, test.wantPriority)
+			}
+			if got := podCfg.Alarms.Pods.Restarts.Threshold; got != test.wantThreshold {
+				t.Errorf("restarts threshold = %d, want %d", got, test.wantThreshold)
+			}
+			if got := podCfg.Alarms.Pods.Terminate.ExcludedReasons; !reflect.DeepEqual(got, test.wantExcludedReasons) {
+				t.Errorf("excluded reasons = %v, want %v", got, test.wantExcludedReasons)
+			}
+			if got := overrides[AnnotationPriority].Source; got != test.wantPrioritySource {
+				t.Errorf("priority source = %q, want %q", got, test.wantPrioritySource)
+			}
+		})
+	}
+
+	if cfg.Alarms.Pods.Terminate.Priority != "HIGH" || cfg.Alarms.Pods.Restarts.Threshold != 10 {
+		t.Errorf("getPodAlarmSettings() modified the global config")
+	}
+}
@@ -0,0 +1,144 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+const FailedScheduling = "FailedScheduling"
+
+func getPodPendingKey(pod *api.Pod) string {
+	return fmt.Sprintf("%s-pending", getPodKey(pod))
+}
+
+func getPodScheduledCondition(pod *api.Pod) *api.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == api.PodScheduled {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func getFailedSchedulingEvents(kubeClient *kubernetes.Clientset, pod *api.Pod) []string {
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": pod.GetName(),
+		"reason":              FailedScheduling,
+	}.AsSelector().String()
+
+	events, err := kubeClient.CoreV1().Events(pod.GetNamespace()).List(context.TODO(), metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		log.Debug().Err(err).Str("pod", pod.GetName()).Str("namespace", pod.GetNamespace()).Msg("Failed to get pod scheduling events")
+		return nil
+	}
+
+	messages := make([]string, 0)
+	for _, event := range events.Items {
+		if event.InvolvedObject.UID != "" && event.InvolvedObject.UID != pod.GetUID() {
+			continue
+		}
+		messages = append(messages, event.Message)
+	}
+	return messages
+}
+
+func getPodPendingDetails(pod *api.Pod, condition *api.PodCondition, since time.Time, events []string) string {
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nPending since: %s",
+		pod.GetName(),
+		pod.GetNamespace(),
+		since.Format(time.RFC3339))
+
+	if condition != nil {
+		details += fmt.Sprintf("\nReason: %s\nMessage: %s", condition.Reason, condition.Message)
+	}
+
+	if len(events) > 0 {
+		details += "\n\nFailedScheduling events:"
+		for _, event := range events {
+			details += fmt.Sprintf("\n- %s", event)
+		}
+	}
+
+	return details
+}
+
+func analyzePodPending(pod *api.Pod, cfg *config.Config) {
+	if !cfg.Alarms.Pods.Pending.Enabled || pod.GetDeletionTimestamp() != nil {
+		return
+	}
+
+	alertKey := getPodPendingKey(pod)
+	duration := getDuration(cfg.Alarms.Pods.Pending.Duration)
+	condition := getPodScheduledCondition(pod)
+
+	if condition != nil && condition.Status == api.ConditionTrue {
+		// Only pods that raised an alert while unscheduled are resolved
+		if !clearProblemSince(alertKey) {
+			return
+		}
+		removeOpenAlert(getPodKey(pod), alertKey)
+		if cfg.Alarms.Pods.SendResolveEvents {
+			labels := getEventLabelsFromPod(pod, cfg.KubeClient)
+			alert.CreateEvent(cfg, alertKey, fmt.Sprintf("Pod %s/%s scheduled", pod.GetNamespace(), pod.GetName()), "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	if pod.Status.Phase != api.PodPending {
+		return
+	}
+
+	if condition != nil && condition.Reason == api.PodReasonSchedulingGated {
+		return
+	}
+
+	since := pod.GetCreationTimestamp().Time
+	if condition != nil && !condition.LastTransitionTime.IsZero() {
+		since = condition.LastTransitionTime.Time
+	}
+	if time.Since(since) < duration {
+		return
+	}
+
+	reason := api.PodReasonUnschedulable
+	message := ""
+	if condition != nil {
+		if condition.Reason != "" {
+			reason = condition.Reason
+		}
+		message = condition.Message
+	}
+
+	labels := getEventLabelsFromPod(pod, cfg.KubeClient)
+	cfg, overrides, enabled := getPodAlarmSettings(pod, labels, cfg)
+	if !enabled {
+		return
+	}
+
+	events := getFailedSchedulingEvents(cfg.KubeClient, pod)
+	summary := fmt.Sprintf("Pod %s/%s pending - %s", pod.GetNamespace(), pod.GetName(), reason)
+	details := getPodPendingDetails(pod, condition, since, events)
+	links := getPodLinks(cfg, pod)
+	customDetails := map[string]interface{}{
+		"reason":                   reason,
+		"message":                  message,
+		"pending_since":            since.Format(time.RFC3339),
+		"failed_scheduling_events": events,
+	}
+	customDetails = addOverrideCustomDetails(customDetails, overrides)
+	// Track the pending pod so its scheduling resolves the alert
+	getProblemSince(alertKey)
+	addOpenAlert(getPodKey(pod), alertKey)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.Pending.Priority, labels, links, nil, customDetails)
+}
@@ -0,0 +1,86 @@
+package watcher
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+)
+
+func getPodResourceStateKey(pod *api.Pod, containerName string, resourceName string) string {
+	return fmt.Sprintf("%s-%s-%s", getPodKey(pod), containerName, resourceName)
+}
+
+// clearPodResourceStates stops tracking the resource usage of all containers and reports whether any of them was alerted
+func clearPodResourceStates(pod *api.Pod) bool {
+	cleared := false
+	for _, container := range pod.Spec.Containers {
+		for _, resourceName := range []string{"cpu-limit", "memory-limit", "cpu-request", "memory-request"} {
+			if clearResourceUsageState(getPodResourceStateKey(pod, container.Name, resourceName)) {
+				cleared = true
+			}
+		}
+	}
+	return cleared
+}
+
+// analyzeContainerRequests compares the container usage to its requests, so containers without limits are checked as well, and reports whether it is healthy
+func analyzeContainerRequests(pod *api.Pod, container *api.Container, cpuUsage float64, memoryUsage int64, labels map[string]string, cfg *config.Config) bool {
+	healthy := true
+	setting := cfg.Alarms.Pods.Resources
+
+	if setting.CPU.Enabled && cpuUsage > 0 {
+		cpuRequest, err := strconv.ParseFloat(container.Resources.Requests.Cpu().AsDec().String(), 64)
+		if err == nil && cpuRequest > 0 {
+			log.Debug().
+				Str("pod", pod.GetName()).
+				Str("namespace", pod.GetNamespace()).
+				Str("container", container.Name).
+				Float64("request", cpuRequest).
+				Float64("usage", cpuUsage).
+				Msg("Checking CPU request")
+			resourceHealthy, alarm := getResourceUsageState(getPodResourceStateKey(pod, container.Name, "cpu-request"), cpuUsage*100/cpuRequest, setting.Requests.Threshold, setting)
+			healthy = healthy && resourceHealthy
+			if alarm {
+				if podCfg, _, enabled := getPodAlarmSettings(pod, labels, cfg); enabled {
+					summary := fmt.Sprintf("Pod %s/%s CPU request utilization > %d%%", pod.GetNamespace(), pod.GetName(), setting.Requests.Threshold)
+					details := getPodDetailsWithUsageLimit(cfg.KubeClient, pod, fmt.Sprintf("%.3f CPU", cpuUsage), "")
+					details += fmt.Sprintf("\nRequest: %.3f CPU\nContainer: %s", cpuRequest, container.Name)
+					links := getPodLinks(cfg, pod)
+					alert.CreateEvent(cfg, getPodKey(pod), summary, details, ilert.EventTypes.Alert, podCfg.Alarms.Pods.Resources.Requests.Priority, labels, links, nil, nil)
+				}
+			}
+		}
+	}
+
+	if setting.Memory.Enabled && memoryUsage > 0 {
+		memoryRequest, ok := container.Resources.Requests.Memory().AsInt64()
+		if ok && memoryRequest > 0 {
+			log.Debug().
+				Str("pod", pod.GetName()).
+				Str("namespace", pod.GetNamespace()).
+				Str("container", container.Name).
+				Int64("request", memoryRequest).
+				Int64("usage", memoryUsage).
+				Msg("Checking memory request")
+			resourceHealthy, alarm := getResourceUsageState(getPodResourceStateKey(pod, container.Name, "memory-request"), float64(memoryUsage)*100/float64(memoryRequest), setting.Requests.Threshold, setting)
+			healthy = healthy && resourceHealthy
+			if alarm {
+				if podCfg, _, enabled := getPodAlarmSettings(pod, labels, cfg); enabled {
+					summary := fmt.Sprintf("Pod %s/%s memory request utilization > %d%%", pod.GetNamespace(), pod.GetName(), setting.Requests.Threshold)
+					details := getPodDetailsWithUsageLimit(cfg.KubeClient, pod, humanize.Bytes(uint64(memoryUsage)), "")
+					details += fmt.Sprintf("\nRequest: %s\nContainer: %s", humanize.Bytes(uint64(memoryRequest)), container.Name)
+					links := getPodLinks(cfg, pod)
+					alert.CreateEvent(cfg, getPodKey(pod), summary, details, ilert.EventTypes.Alert, podCfg.Alarms.Pods.Resources.Requests.Priority, labels, links, nil, nil)
+				}
+			}
+		}
+	}
+
+	return healthy
+}
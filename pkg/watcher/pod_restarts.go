@@ -0,0 +1,88 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	api "k8s.io/api/core/v1"
+)
+
+// These are the valid restarts alarm modes
+const (
+	RestartsModeAbsolute = "absolute"
+	RestartsModeRate     = "rate"
+)
+
+// containerRestarts is the restart history of a container, the count is the last observed restart count
+type containerRestarts struct {
+	Count    int32       `json:"count"`
+	Restarts []time.Time `json:"restarts"`
+}
+
+// container names are unique across all container types of a pod
+func getContainerRestartsKey(pod *api.Pod, containerName string) string {
+	return fmt.Sprintf("state:restarts:%s-%s", getPodKey(pod), containerName)
+}
+
+// getContainerRestartsWithinWindow records the restart delta since the last observation and returns the restarts within the window,
+// containers without restarts have no state
+func getContainerRestartsWithinWindow(pod *api.Pod, containerStatus *api.ContainerStatus, window time.Duration) int32 {
+	now := time.Now()
+	stateKey := getContainerRestartsKey(pod, containerStatus.Name)
+
+	state := containerRestarts{}
+	value, err := cache.Cache.State.GetItem(stateKey)
+	if err == nil && value != "" && json.Unmarshal([]byte(value), &state) == nil {
+		// A lower restart count means the pod was recreated with the same name
+		if containerStatus.RestartCount < state.Count {
+			state.Restarts = nil
+			state.Count = 0
+		}
+		for i := state.Count; i < containerStatus.RestartCount; i++ {
+			state.Restarts = append(state.Restarts, now)
+		}
+	} else if containerStatus.RestartCount == 0 {
+		return 0
+	} else if terminated := containerStatus.LastTerminationState.Terminated; terminated != nil && !terminated.FinishedAt.IsZero() {
+		// The earlier restarts of a container seen for the first time happened at an unknown time, only the last one is known
+		state.Restarts = append(state.Restarts, terminated.FinishedAt.Time)
+	}
+	state.Count = containerStatus.RestartCount
+
+	restarts := make([]time.Time, 0, len(state.Restarts))
+	for _, restart := range state.Restarts {
+		if now.Sub(restart) < window {
+			restarts = append(restarts, restart)
+		}
+	}
+	state.Restarts = restarts
+
+	if data, err := json.Marshal(state); err == nil {
+		cache.Cache.State.SetItem(stateKey, string(data), problemStateTTL)
+	}
+	return int32(len(restarts))
+}
+
+// clearContainerRestarts removes the restart history of all containers of the deleted pod
+func clearContainerRestarts(pod *api.Pod) {
+	for _, container := range pod.Spec.InitContainers {
+		cache.Cache.State.DeleteItem(getContainerRestartsKey(pod, container.Name))
+	}
+	for _, container := range pod.Spec.Containers {
+		cache.Cache.State.DeleteItem(getContainerRestartsKey(pod, container.Name))
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		cache.Cache.State.DeleteItem(getContainerRestartsKey(pod, container.Name))
+	}
+}
+
+// getContainerRestarts returns the restarts compared to the threshold depending on the configured mode
+func getContainerRestarts(pod *api.Pod, containerStatus *api.ContainerStatus, setting config.ConfigAlarmSettingRestarts) int32 {
+	if setting.Mode == RestartsModeRate {
+		return getContainerRestartsWithinWindow(pod, containerStatus, getDuration(setting.Window))
+	}
+	return containerStatus.RestartCount
+}
@@ -0,0 +1,84 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/iLert/ilert-kube-agent/pkg/cache"
+)
+
+func TestGetContainerRestartsWithinWindow(t *testing.T) {
+	cache.Cache.Init(100)
+
+	type observation struct {
+		restartCount int32
+		lastFinished time.Duration
+		want         int32
+	}
+
+	tests := []struct {
+		name         string
+		window       time.Duration
+		observations []observation
+	}{
+		{
+			name:         "no restarts",
+			window:       time.Hour,
+			observations: []observation{{restartCount: 0, want: 0}, {restartCount: 0, want: 0}},
+		},
+		{
+			name:   "restarts since the first observation",
+			window: time.Hour,
+			observations: []observation{
+				{restartCount: 1, lastFinished: time.Minute, want: 1},
+				{restartCount: 3, lastFinished: time.Minute, want: 3},
+				{restartCount: 4, lastFinished: time.Minute, want: 4},
+			},
+		},
+		{
+			name:   "only the last restart of a new container is known",
+			window: time.Hour,
+			observations: []observation{
+				{restartCount: 5, lastFinished: 10 * time.Minute, want: 1},
+				{restartCount: 6, want: 2},
+			},
+		},
+		{
+			name:   "last restart outside of the window",
+			window: time.Hour,
+			observations: []observation{
+				{restartCount: 5, lastFinished: 2 * time.Hour, want: 0},
+				{restartCount: 6, want: 1},
+			},
+		},
+		{
+			name:   "recreated pod with a lower restart count",
+			window: time.Hour,
+			observations: []observation{
+				{restartCount: 1, lastFinished: time.Minute, want: 1},
+				{restartCount: 3, lastFinished: time.Minute, want: 3},
+				{restartCount: 1, lastFinished: time.Minute, want: 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: test.name}}
+			for i, observation := range test.observations {
+				containerStatus := &api.ContainerStatus{Name: "app", RestartCount: observation.restartCount}
+				if observation.lastFinished > 0 {
+					containerStatus.LastTerminationState.Terminated = &api.ContainerStateTerminated{
+						FinishedAt: metav1.NewTime(time.Now().Add(-observation.lastFinished)),
+					}
+				}
+				if got := getContainerRestartsWithinWindow(pod, containerStatus, test.window); got != observation.want {
+					t.Errorf("observation %d: getContainerRestartsWithinWindow() = %d, want %d", i, got, observation.want)
+				}
+			}
+		})
+	}
+}
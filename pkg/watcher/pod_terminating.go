@@ -0,0 +1,137 @@
+package watcher
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/utils"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func getPodTerminatingKey(pod *api.Pod) string {
+	return fmt.Sprintf("%s-terminating", getPodKey(pod))
+}
+
+// getPodDeleteURL returns the commander endpoint to force delete the pod, the request method has to be DELETE so it is
+// never added as alert link. A force delete does not remove the finalizers of the pod
+func getPodDeleteURL(cfg *config.Config, pod *api.Pod) string {
+	if cfg.Settings.ExternalURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/api/pods/%s?namespace=%s&gracePeriodSeconds=0",
+		strings.TrimSuffix(cfg.Settings.ExternalURL, "/"),
+		url.PathEscape(pod.GetName()),
+		url.QueryEscape(pod.GetNamespace()))
+}
+
+func getPodNodeReadyStatus(kubeClient *kubernetes.Clientset, nodeName string) string {
+	if nodeName == "" {
+		return ""
+	}
+
+	node, err := getNode(kubeClient, nodeName)
+	if err != nil {
+		log.Debug().Err(err).Str("node", nodeName).Msg("Failed to get pod node")
+		return ""
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == api.NodeReady {
+			return string(condition.Status)
+		}
+	}
+	return string(api.ConditionUnknown)
+}
+
+func analyzePodTerminating(pod *api.Pod, cfg *config.Config) {
+	if !cfg.Alarms.Pods.Terminating.Enabled || pod.GetDeletionTimestamp() == nil {
+		return
+	}
+
+	// The deletion timestamp already includes the grace period of the pod
+	deadline := pod.GetDeletionTimestamp().Time
+	if time.Since(deadline) < getDuration(cfg.Alarms.Pods.Terminating.Duration) {
+		return
+	}
+
+	labels := getEventLabelsFromPod(pod, cfg.KubeClient)
+	cfg, overrides, enabled := getPodAlarmSettings(pod, labels, cfg)
+	if !enabled {
+		return
+	}
+
+	// Track the stuck pod so its removal resolves the alert
+	getProblemSince(getPodTerminatingKey(pod))
+
+	finalizers := pod.GetFinalizers()
+	nodeReady := getPodNodeReadyStatus(cfg.KubeClient, pod.Spec.NodeName)
+	deleteURL := getPodDeleteURL(cfg, pod)
+
+	summary := fmt.Sprintf("Pod %s/%s stuck terminating since %s", pod.GetNamespace(), pod.GetName(), deadline.Format(time.RFC3339))
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nNode: %s\nNode ready: %s\nDeletion deadline: %s",
+		pod.GetName(),
+		pod.GetNamespace(),
+		pod.Spec.NodeName,
+		nodeReady,
+		deadline.Format(time.RFC3339))
+	if pod.GetDeletionGracePeriodSeconds() != nil {
+		details += fmt.Sprintf("\nGrace period: %ds", *pod.GetDeletionGracePeriodSeconds())
+	}
+	if len(finalizers) > 0 {
+		details += fmt.Sprintf("\nBlocking finalizers: %s", strings.Join(finalizers, ", "))
+	}
+	if deleteURL != "" {
+		details += fmt.Sprintf("\n\nForce delete: DELETE %s", deleteURL)
+		if len(finalizers) > 0 {
+			details += "\nThe finalizers are not removed by a force delete, they have to be removed separately"
+		}
+	}
+
+	links := getPodLinks(cfg, pod)
+	customDetails := map[string]interface{}{
+		"deletion_timestamp": deadline.Format(time.RFC3339),
+		"finalizers":         finalizers,
+		"node":               pod.Spec.NodeName,
+		"node_ready":         nodeReady,
+	}
+	if deleteURL != "" {
+		customDetails["force_delete_endpoint"] = fmt.Sprintf("DELETE %s", deleteURL)
+	}
+	customDetails = addOverrideCustomDetails(customDetails, overrides)
+	alert.CreateEvent(cfg, getPodTerminatingKey(pod), summary, details, ilert.EventTypes.Alert, cfg.Alarms.Pods.Terminating.Priority, labels, links, nil, customDetails)
+}
+
+// analyzePodDeleted resolves the alerts that are still open for the deleted pod, none of them can recover anymore
+func analyzePodDeleted(pod *api.Pod, cfg *config.Config) {
+	podKey := getPodKey(pod)
+	deleteContainerMemoryUsages(pod)
+	clearContainerRestarts(pod)
+	releaseWorkloadPodAlerts(pod, cfg)
+
+	alertKeys := append(takeOpenAlerts(podKey), takeOpenAlerts(getPodContainersKey(pod))...)
+
+	if clearProblemSince(getPodTerminatingKey(pod)) {
+		alertKeys = append(alertKeys, getPodTerminatingKey(pod))
+	}
+	if clearPodResourceStates(pod) && !utils.StringContains(alertKeys, podKey) {
+		alertKeys = append(alertKeys, podKey)
+	}
+	alertKeys = append(alertKeys, clearPodMemoryPredictions(pod)...)
+
+	if len(alertKeys) == 0 || !cfg.Alarms.Pods.SendResolveEvents {
+		return
+	}
+
+	// The resolves are sent with the labels of the alerts, these labels are only the fallback
+	labels := getEventLabelsFromDeletedPod(pod)
+	summary := fmt.Sprintf("Pod %s/%s deleted", pod.GetNamespace(), pod.GetName())
+	for _, alertKey := range alertKeys {
+		alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+	}
+}
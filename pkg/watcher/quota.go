@@ -0,0 +1,216 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const quotaTopConsumers = 5
+
+type quotaConsumer struct {
+	Pod   string
+	Usage resource.Quantity
+}
+
+func getQuotaKey(quota *api.ResourceQuota, resourceName api.ResourceName) string {
+	return fmt.Sprintf("%s/quota/%s-%s", quota.GetNamespace(), quota.GetName(), resourceName)
+}
+
+// getQuotaPodResource maps a quota resource e.g. limits.cpu to the container resource field it counts
+func getQuotaPodResource(resourceName api.ResourceName) (api.ResourceName, bool, bool) {
+	name := string(resourceName)
+	switch {
+	case strings.HasPrefix(name, "requests."):
+		return api.ResourceName(strings.TrimPrefix(name, "requests.")), false, name != "requests.storage"
+	case strings.HasPrefix(name, "limits."):
+		return api.ResourceName(strings.TrimPrefix(name, "limits.")), true, true
+	case resourceName == api.ResourceCPU || resourceName == api.ResourceMemory || resourceName == api.ResourceEphemeralStorage:
+		return resourceName, false, true
+	}
+	return "", false, false
+}
+
+func getContainerQuotaUsage(container api.Container, resourceName api.ResourceName, limits bool) resource.Quantity {
+	resources := container.Resources.Requests
+	if limits {
+		resources = container.Resources.Limits
+	}
+	return resources[resourceName].DeepCopy()
+}
+
+// getPodQuotaUsage returns the pod usage the way the quota counts it, the init containers run one after another except the
+// sidecars that keep running next to the app containers, so the larger of the app containers and any init container step counts
+func getPodQuotaUsage(pod *api.Pod, resourceName api.ResourceName, limits bool) resource.Quantity {
+	usage := resource.Quantity{}
+	for _, container := range pod.Spec.Containers {
+		usage.Add(getContainerQuotaUsage(container, resourceName, limits))
+	}
+
+	sidecars := resource.Quantity{}
+	initUsage := resource.Quantity{}
+	for _, container := range pod.Spec.InitContainers {
+		containerUsage := getContainerQuotaUsage(container, resourceName, limits)
+		if container.RestartPolicy != nil && *container.RestartPolicy == api.ContainerRestartPolicyAlways {
+			sidecars.Add(containerUsage)
+			containerUsage = sidecars.DeepCopy()
+		} else {
+			containerUsage.Add(sidecars)
+		}
+		if containerUsage.Cmp(initUsage) > 0 {
+			initUsage = containerUsage
+		}
+	}
+
+	usage.Add(sidecars)
+	if initUsage.Cmp(usage) > 0 {
+		usage = initUsage
+	}
+	if overhead, ok := pod.Spec.Overhead[resourceName]; ok {
+		usage.Add(overhead)
+	}
+	return usage
+}
+
+// getQuotaTopConsumers returns the pods of the namespace with the highest usage of the quota resource
+func getQuotaTopConsumers(kubeClient *kubernetes.Clientset, namespace string, resourceName api.ResourceName) []quotaConsumer {
+	podResource, limits, ok := getQuotaPodResource(resourceName)
+	if !ok {
+		return nil
+	}
+
+	pods, err := listNamespacePods(kubeClient, namespace)
+	if err != nil {
+		log.Debug().Err(err).Str("namespace", namespace).Msg("Failed to get quota namespace pods")
+		return nil
+	}
+
+	consumers := make([]quotaConsumer, 0)
+	for _, pod := range pods {
+		// Terminal pods are not counted by the quota
+		if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		usage := getPodQuotaUsage(pod, podResource, limits)
+		if usage.IsZero() {
+			continue
+		}
+		consumers = append(consumers, quotaConsumer{Pod: pod.GetName(), Usage: usage})
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		return consumers[i].Usage.Cmp(consumers[j].Usage) > 0
+	})
+	if len(consumers) > quotaTopConsumers {
+		consumers = consumers[:quotaTopConsumers]
+	}
+	return consumers
+}
+
+// getQuotaPercentage returns used/hard in percent, the milli values of large storage quotas overflow int64
+func getQuotaPercentage(used resource.Quantity, hard resource.Quantity) int64 {
+	return int64(used.AsApproximateFloat64() * 100 / hard.AsApproximateFloat64())
+}
+
+func getQuotaDetails(quota *api.ResourceQuota, resourceName api.ResourceName, used resource.Quantity, hard resource.Quantity, consumers []quotaConsumer) string {
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nResource: %s\nUsed: %s\nHard: %s",
+		quota.GetName(),
+		quota.GetNamespace(),
+		resourceName,
+		used.String(),
+		hard.String())
+
+	if len(consumers) > 0 {
+		details += "\n\nTop consumers:"
+		for _, consumer := range consumers {
+			details += fmt.Sprintf("\n- %s: %s", consumer.Pod, consumer.Usage.String())
+		}
+	}
+	return details
+}
+
+func analyzeQuotaResource(quota *api.ResourceQuota, resourceName api.ResourceName, hard resource.Quantity, cfg *config.Config) {
+	if hard.IsZero() {
+		return
+	}
+
+	used := quota.Status.Used[resourceName]
+	percentage := getQuotaPercentage(used, hard)
+	alertKey := getQuotaKey(quota, resourceName)
+	quotaName := fmt.Sprintf("%s/%s", quota.GetNamespace(), quota.GetName())
+	labels := map[string]string{
+		"namespace":       quota.GetNamespace(),
+		"quota":           quota.GetName(),
+		"resource":        string(resourceName),
+		"resourceVersion": quota.GetResourceVersion(),
+	}
+
+	if percentage < int64(cfg.Alarms.Quotas.Usage.Threshold) {
+		if clearProblemSince(alertKey) && cfg.Alarms.Quotas.SendResolveEvents {
+			summary := fmt.Sprintf("Resource quota %s %s usage is below %d%%", quotaName, resourceName, cfg.Alarms.Quotas.Usage.Threshold)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	since := getProblemSince(alertKey)
+	consumers := getQuotaTopConsumers(cfg.KubeClient, quota.GetNamespace(), resourceName)
+	consumerDetails := make([]map[string]string, 0, len(consumers))
+	for _, consumer := range consumers {
+		consumerDetails = append(consumerDetails, map[string]string{
+			"pod":   consumer.Pod,
+			"usage": consumer.Usage.String(),
+		})
+	}
+
+	summary := fmt.Sprintf("Resource quota %s %s usage reached %d%%", quotaName, resourceName, percentage)
+	details := getQuotaDetails(quota, resourceName, used, hard, consumers)
+	customDetails := map[string]interface{}{
+		"quota":           quota.GetName(),
+		"resource":        string(resourceName),
+		"used":            used.String(),
+		"hard":            hard.String(),
+		"percentage":      percentage,
+		"threshold":       cfg.Alarms.Quotas.Usage.Threshold,
+		"top_consumers":   consumerDetails,
+		"unhealthy_since": since.Format(time.RFC3339),
+	}
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Quotas.Usage.Priority, labels, nil, nil, customDetails)
+}
+
+func analyzeQuota(quota *api.ResourceQuota, cfg *config.Config) {
+	if !cfg.Alarms.Quotas.Enabled || !cfg.Alarms.Quotas.Usage.Enabled {
+		return
+	}
+
+	for resourceName, hard := range quota.Status.Hard {
+		analyzeQuotaResource(quota, resourceName, hard, cfg)
+	}
+}
+
+func analyzeQuotas(cfg *config.Config) error {
+	quotas, err := cfg.KubeClient.CoreV1().ResourceQuotas(getScopeNamespace(cfg)).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to get resource quotas")
+		return err
+	}
+
+	for i := range quotas.Items {
+		if !isNamespaceInScope(quotas.Items[i].GetNamespace(), cfg) {
+			continue
+		}
+		analyzeQuota(&quotas.Items[i], cfg)
+	}
+	return analyzeLimitRanges(cfg)
+}
@@ -0,0 +1,50 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var quotaCheckerCron *cron.Cron
+
+func startQuotaChecker(cfg *config.Config) {
+	quotaCheckerCron = cron.New()
+	quotaCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkQuotas(cfg)
+	})
+
+	log.Info().Msg("Starting quotas checker")
+	quotaCheckerCron.Start()
+}
+
+func stopQuotaChecker() {
+	if quotaCheckerCron != nil {
+		log.Info().Msg("Stopping quotas checker")
+		quotaCheckerCron.Stop()
+		quotaCheckerCron = nil
+	}
+}
+
+func checkQuotas(cfg *config.Config) {
+	defer memory.RecoverPanic("quota-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping quota check due to memory pressure")
+			return
+		}
+	}
+
+	log.Debug().Msg("Running quotas check")
+
+	err := analyzeQuotas(cfg)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check resource quotas")
+	}
+}
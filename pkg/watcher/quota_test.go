@@ -0,0 +1,163 @@
+package watcher
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetQuotaPodResource(t *testing.T) {
+	tests := []struct {
+		resourceName api.ResourceName
+		want         api.ResourceName
+		wantLimits   bool
+		wantOK       bool
+	}{
+		{resourceName: "requests.cpu", want: api.ResourceCPU, wantOK: true},
+		{resourceName: "requests.memory", want: api.ResourceMemory, wantOK: true},
+		{resourceName: "limits.memory", want: api.ResourceMemory, wantLimits: true, wantOK: true},
+		{resourceName: "cpu", want: api.ResourceCPU, wantOK: true},
+		{resourceName: "ephemeral-storage", want: api.ResourceEphemeralStorage, wantOK: true},
+		{resourceName: "requests.storage", want: api.ResourceStorage, wantOK: false},
+		{resourceName: "pods", wantOK: false},
+		{resourceName: "count/deployments.apps", wantOK: false},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.resourceName), func(t *testing.T) {
+			got, limits, ok := getQuotaPodResource(test.resourceName)
+			if ok != test.wantOK || (ok && (got != test.want || limits != test.wantLimits)) {
+				t.Errorf("getQuotaPodResource() = %v, %v, %v, want %v, %v, %v", got, limits, ok, test.want, test.wantLimits, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetQuotaPercentage(t *testing.T) {
+	tests := []struct {
+		name string
+		used string
+		hard string
+		want int64
+	}{
+		{name: "cpu", used: "900m", hard: "1", want: 90},
+		{name: "memory", used: "1Gi", hard: "4Gi", want: 25},
+		{name: "pods", used: "10", hard: "10", want: 100},
+		{name: "over", used: "12", hard: "10", want: 120},
+		{name: "large storage", used: "9Pi", hard: "10Pi", want: 90},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getQuotaPercentage(resource.MustParse(test.used), resource.MustParse(test.hard)); got != test.want {
+				t.Errorf("getQuotaPercentage() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func quotaContainer(name string, request string) api.Container {
+	return api.Container{
+		Name: name,
+		Resources: api.ResourceRequirements{
+			Requests: api.ResourceList{api.ResourceCPU: resource.MustParse(request)},
+			Limits:   api.ResourceList{api.ResourceCPU: resource.MustParse(request)},
+		},
+	}
+}
+
+func TestGetPodQuotaUsage(t *testing.T) {
+	always := api.ContainerRestartPolicyAlways
+	sidecar := quotaContainer("sidecar", "200m")
+	sidecar.RestartPolicy = &always
+
+	tests := []struct {
+		name string
+		spec api.PodSpec
+		want string
+	}{
+		{
+			name: "app containers",
+			spec: api.PodSpec{Containers: []api.Container{quotaContainer("a", "100m"), quotaContainer("b", "200m")}},
+			want: "300m",
+		},
+		{
+			name: "init container below the app containers",
+			spec: api.PodSpec{
+				InitContainers: []api.Container{quotaContainer("init", "200m")},
+				Containers:     []api.Container{quotaContainer("a", "300m")},
+			},
+			want: "300m",
+		},
+		{
+			name: "init container above the app containers",
+			spec: api.PodSpec{
+				InitContainers: []api.Container{quotaContainer("init", "1")},
+				Containers:     []api.Container{quotaContainer("a", "300m")},
+			},
+			want: "1",
+		},
+		{
+			name: "sidecar runs next to the app containers",
+			spec: api.PodSpec{
+				InitContainers: []api.Container{sidecar, quotaContainer("init", "400m")},
+				Containers:     []api.Container{quotaContainer("a", "300m")},
+			},
+			want: "600m",
+		},
+		{
+			name: "overhead",
+			spec: api.PodSpec{
+				Containers: []api.Container{quotaContainer("a", "300m")},
+				Overhead:   api.ResourceList{api.ResourceCPU: resource.MustParse("50m")},
+			},
+			want: "350m",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &api.Pod{Spec: test.spec}
+			for _, limits := range []bool{false, true} {
+				got := getPodQuotaUsage(pod, api.ResourceCPU, limits)
+				if got.Cmp(resource.MustParse(test.want)) != 0 {
+					t.Errorf("getPodQuotaUsage(limits=%v) = %s, want %s", limits, got.String(), test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetLimitRangeConsumers(t *testing.T) {
+	requestOnly := api.Container{
+		Name:      "request-only",
+		Resources: api.ResourceRequirements{Requests: api.ResourceList{api.ResourceCPU: resource.MustParse("1900m")}},
+	}
+	pods := []*api.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "api"},
+			Spec: api.PodSpec{
+				InitContainers: []api.Container{quotaContainer("migrate", "2")},
+				Containers:     []api.Container{quotaContainer("app", "1"), requestOnly},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "done"},
+			Spec:       api.PodSpec{Containers: []api.Container{quotaContainer("app", "2")}},
+			Status:     api.PodStatus{Phase: api.PodSucceeded},
+		},
+	}
+
+	consumers := getLimitRangeConsumers(pods, api.ResourceCPU, resource.MustParse("2"), 90)
+	if len(consumers) != 2 {
+		t.Fatalf("consumers = %v, want 2", consumers)
+	}
+	if consumers[0].Container != "api/migrate" || consumers[0].Percentage != 100 {
+		t.Errorf("first consumer = %v, want api/migrate at 100%%", consumers[0])
+	}
+	if consumers[1].Container != "api/request-only" || consumers[1].Percentage != 95 {
+		t.Errorf("second consumer = %v, want api/request-only at 95%%", consumers[1])
+	}
+}
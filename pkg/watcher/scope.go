@@ -0,0 +1,96 @@
+package watcher
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+var (
+	scopedFactory informers.SharedInformerFactory
+	podFactory    informers.SharedInformerFactory
+	eventFactory  informers.SharedInformerFactory
+)
+
+func matchNamespace(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, namespace); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isNamespaceInScope returns false for namespaces excluded by the scope, cluster scoped objects are always in scope
+func isNamespaceInScope(namespace string, cfg *config.Config) bool {
+	if namespace == "" {
+		return true
+	}
+	if len(cfg.Settings.Scope.IncludeNamespaces) > 0 && !matchNamespace(cfg.Settings.Scope.IncludeNamespaces, namespace) {
+		return false
+	}
+	return !matchNamespace(cfg.Settings.Scope.ExcludeNamespaces, namespace)
+}
+
+// isInScope checks the namespace and the label selector of namespaced objects, the informers and lists already filter by selector
+// but namespace patterns can only be matched on the client side
+func isInScope(obj metav1.Object, cfg *config.Config) bool {
+	if obj.GetNamespace() == "" {
+		return true
+	}
+	if !isNamespaceInScope(obj.GetNamespace(), cfg) {
+		return false
+	}
+
+	selector, err := labels.Parse(cfg.Settings.Scope.LabelSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// getScopeNamespace returns the namespace to watch, only a single include namespace without pattern can be watched directly
+func getScopeNamespace(cfg *config.Config) string {
+	if len(cfg.Settings.Scope.IncludeNamespaces) == 1 && !strings.ContainsAny(cfg.Settings.Scope.IncludeNamespaces[0], "*?[") {
+		return cfg.Settings.Scope.IncludeNamespaces[0]
+	}
+	return metav1.NamespaceAll
+}
+
+func getScopeListOptions(cfg *config.Config) metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: cfg.Settings.Scope.LabelSelector}
+}
+
+// getPodScopeListOptions returns the list options for pods, the field selector is only supported for pods
+func getPodScopeListOptions(cfg *config.Config) metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: cfg.Settings.Scope.LabelSelector,
+		FieldSelector: cfg.Settings.Scope.FieldSelector,
+	}
+}
+
+// initFactories creates the informer factories once before the informers are started concurrently,
+// the shared factory is used for cluster scoped objects and the scoped factories for namespaced objects
+func initFactories(cfg *config.Config) {
+	sharedFactory = informers.NewSharedInformerFactory(cfg.KubeClient, 15*time.Minute)
+	scopedFactory = informers.NewSharedInformerFactoryWithOptions(cfg.KubeClient, 15*time.Minute,
+		informers.WithNamespace(getScopeNamespace(cfg)),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = cfg.Settings.Scope.LabelSelector
+		}))
+	podFactory = informers.NewSharedInformerFactoryWithOptions(cfg.KubeClient, 15*time.Minute,
+		informers.WithNamespace(getScopeNamespace(cfg)),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = cfg.Settings.Scope.LabelSelector
+			options.FieldSelector = cfg.Settings.Scope.FieldSelector
+		}))
+	// events don't carry the labels of the involved object, so only the namespace scope applies
+	eventFactory = informers.NewSharedInformerFactoryWithOptions(cfg.KubeClient, 15*time.Minute,
+		informers.WithNamespace(getScopeNamespace(cfg)))
+}
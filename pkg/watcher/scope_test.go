@@ -0,0 +1,99 @@
+package watcher
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+)
+
+func TestIsNamespaceInScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     config.ConfigSettingsScope
+		namespace string
+		want      bool
+	}{
+		{
+			name:      "no scope",
+			namespace: "default",
+			want:      true,
+		},
+		{
+			name:      "cluster scoped object",
+			scope:     config.ConfigSettingsScope{IncludeNamespaces: []string{"team-a"}},
+			namespace: "",
+			want:      true,
+		},
+		{
+			name:      "included namespace",
+			scope:     config.ConfigSettingsScope{IncludeNamespaces: []string{"team-a"}},
+			namespace: "team-a",
+			want:      true,
+		},
+		{
+			name:      "not included namespace",
+			scope:     config.ConfigSettingsScope{IncludeNamespaces: []string{"team-a"}},
+			namespace: "team-b",
+			want:      false,
+		},
+		{
+			name:      "included glob",
+			scope:     config.ConfigSettingsScope{IncludeNamespaces: []string{"team-*"}},
+			namespace: "team-b",
+			want:      true,
+		},
+		{
+			name:      "excluded glob",
+			scope:     config.ConfigSettingsScope{ExcludeNamespaces: []string{"kube-*"}},
+			namespace: "kube-system",
+			want:      false,
+		},
+		{
+			name:      "exclude takes precedence over include",
+			scope:     config.ConfigSettingsScope{IncludeNamespaces: []string{"team-*"}, ExcludeNamespaces: []string{"team-test"}},
+			namespace: "team-test",
+			want:      false,
+		},
+		{
+			name:      "invalid pattern never matches",
+			scope:     config.ConfigSettingsScope{ExcludeNamespaces: []string{"team-["}},
+			namespace: "team-a",
+			want:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Settings.Scope = test.scope
+			if got := isNamespaceInScope(test.namespace, cfg); got != test.want {
+				t.Errorf("isNamespaceInScope() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetScopeNamespace(t *testing.T) {
+	tests := []struct {
+		name              string
+		includeNamespaces []string
+		want              string
+	}{
+		{name: "no include", want: metav1.NamespaceAll},
+		{name: "single namespace", includeNamespaces: []string{"team-a"}, want: "team-a"},
+		{name: "single pattern", includeNamespaces: []string{"team-*"}, want: metav1.NamespaceAll},
+		{name: "multiple namespaces", includeNamespaces: []string{"team-a", "team-b"}, want: metav1.NamespaceAll},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Settings.Scope.IncludeNamespaces = test.includeNamespaces
+			if got := getScopeNamespace(cfg); got != test.want {
+				t.Errorf("getScopeNamespace() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
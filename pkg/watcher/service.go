@@ -0,0 +1,175 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/utils"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+func getServiceKey(service *api.Service) string {
+	return fmt.Sprintf("%s/service/%s", service.GetNamespace(), service.GetName())
+}
+
+// isServiceSelected returns true for services with a pod selector matching the configured namespaces and label selector
+func isServiceSelected(service *api.Service, cfg *config.Config) bool {
+	if service.Spec.Type == api.ServiceTypeExternalName || len(service.Spec.Selector) == 0 {
+		return false
+	}
+	if len(cfg.Alarms.Services.Namespaces) > 0 && !utils.StringContains(cfg.Alarms.Services.Namespaces, service.GetNamespace()) {
+		return false
+	}
+
+	selector, err := labels.Parse(cfg.Alarms.Services.LabelSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(service.GetLabels()))
+}
+
+func getReadyEndpoints(endpointSlices []*discovery.EndpointSlice) (int, int) {
+	ready := 0
+	total := 0
+	for _, endpointSlice := range endpointSlices {
+		for _, endpoint := range endpointSlice.Endpoints {
+			total++
+			// A nil ready condition has to be interpreted as ready
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				ready++
+			}
+		}
+	}
+	return ready, total
+}
+
+func getServicePods(kubeClient *kubernetes.Clientset, service *api.Service) ([]api.Pod, error) {
+	pods, err := kubeClient.CoreV1().Pods(service.GetNamespace()).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(service.Spec.Selector).String(),
+	})
+	if err != nil {
+		log.Debug().Err(err).Str("service", service.GetName()).Str("namespace", service.GetNamespace()).Msg("Failed to get service pods")
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func isPodReady(pod *api.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == api.PodReady {
+			return condition.Status == api.ConditionTrue
+		}
+	}
+	return false
+}
+
+func getEventLabelsFromService(service *api.Service) map[string]string {
+	return alert.WithObjectLabels(map[string]string{
+		"namespace":       service.GetNamespace(),
+		"service":         service.GetName(),
+		"resourceVersion": service.GetResourceVersion(),
+	}, service.GetLabels())
+}
+
+func getServiceDetails(service *api.Service, pods []api.Pod, ready int, total int) string {
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nType: %s\nSelector: %s\nReady endpoints: %d/%d",
+		service.GetName(),
+		service.GetNamespace(),
+		service.Spec.Type,
+		labels.SelectorFromSet(service.Spec.Selector).String(),
+		ready,
+		total)
+
+	details += "\n\nPods:"
+	if len(pods) == 0 {
+		details += "\nNo pods match the selector"
+	}
+	for _, pod := range pods {
+		details += fmt.Sprintf("\n- %s (phase: %s, ready: %v, node: %s)", pod.GetName(), pod.Status.Phase, isPodReady(&pod), pod.Spec.NodeName)
+	}
+	return details
+}
+
+func getServiceCustomDetails(service *api.Service, pods []api.Pod, ready int, total int) map[string]interface{} {
+	podDetails := make([]map[string]interface{}, 0, len(pods))
+	for _, pod := range pods {
+		podDetails = append(podDetails, map[string]interface{}{
+			"name":  pod.GetName(),
+			"phase": string(pod.Status.Phase),
+			"ready": isPodReady(&pod),
+			"node":  pod.Spec.NodeName,
+		})
+	}
+	return map[string]interface{}{
+		"service":         service.GetName(),
+		"selector":        service.Spec.Selector,
+		"ready_endpoints": ready,
+		"endpoints":       total,
+		"pods":            podDetails,
+	}
+}
+
+func analyzeService(service *api.Service, endpointSlices []*discovery.EndpointSlice, cfg *config.Config) {
+	if !cfg.Alarms.Services.Enabled || service.GetDeletionTimestamp() != nil || !isInScope(service, cfg) || !isServiceSelected(service, cfg) {
+		return
+	}
+
+	alertKey := getServiceKey(service)
+	labels := getEventLabelsFromService(service)
+	serviceName := fmt.Sprintf("%s/%s", service.GetNamespace(), service.GetName())
+
+	ready, total := getReadyEndpoints(endpointSlices)
+	if ready > 0 {
+		if clearProblemSince(alertKey) && cfg.Alarms.Services.SendResolveEvents {
+			summary := fmt.Sprintf("Service %s has %d ready endpoints again", serviceName, ready)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	// A service without any endpoints and pods is backed by workloads scaled to zero on purpose
+	var pods []api.Pod
+	if total == 0 {
+		servicePods, err := getServicePods(cfg.KubeClient, service)
+		if err == nil && len(servicePods) == 0 {
+			if clearProblemSince(alertKey) && cfg.Alarms.Services.SendResolveEvents {
+				summary := fmt.Sprintf("Service %s has no pods, the backing workloads are scaled down", serviceName)
+				alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+			}
+			return
+		}
+		pods = servicePods
+	}
+
+	since := getProblemSince(alertKey)
+	if time.Since(since) < getDuration(cfg.Alarms.Services.Duration) {
+		return
+	}
+
+	if total > 0 {
+		pods, _ = getServicePods(cfg.KubeClient, service)
+	}
+	summary := fmt.Sprintf("Service %s has no ready endpoints", serviceName)
+	details := getServiceDetails(service, pods, ready, total)
+	customDetails := getServiceCustomDetails(service, pods, ready, total)
+	customDetails["unhealthy_since"] = since.Format(time.RFC3339)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, cfg.Alarms.Services.Priority, labels, nil, nil, customDetails)
+}
+
+// analyzeServiceDeleted resolves the alert of the removed service
+func analyzeServiceDeleted(service *api.Service, cfg *config.Config) {
+	alertKey := getServiceKey(service)
+	if clearProblemSince(alertKey) && cfg.Alarms.Services.SendResolveEvents {
+		summary := fmt.Sprintf("Service %s/%s deleted", service.GetNamespace(), service.GetName())
+		alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", getEventLabelsFromService(service), nil, nil, nil)
+	}
+}
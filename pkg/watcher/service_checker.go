@@ -0,0 +1,69 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var serviceCheckerCron *cron.Cron
+
+func startServiceChecker(cfg *config.Config) {
+	serviceCheckerCron = cron.New()
+	serviceCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkServices(cfg)
+	})
+
+	log.Info().Msg("Starting services checker")
+	serviceCheckerCron.Start()
+}
+
+func stopServiceChecker() {
+	if serviceCheckerCron != nil {
+		log.Info().Msg("Stopping services checker")
+		serviceCheckerCron.Stop()
+		serviceCheckerCron = nil
+	}
+}
+
+func checkServices(cfg *config.Config) {
+	defer memory.RecoverPanic("service-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping service check due to memory pressure")
+			return
+		}
+	}
+
+	informers := GetServiceInformers()
+	if informers == nil {
+		log.Warn().Msg("Service informers not available, skipping service check")
+		return
+	}
+
+	for _, informer := range informers {
+		if !cache.WaitForCacheSync(nil, informer.HasSynced) {
+			log.Warn().Msg("Service informers cache not synced, skipping service check")
+			return
+		}
+	}
+
+	log.Debug().Msg("Running services check")
+
+	for _, obj := range serviceInformer.GetStore().List() {
+		service, ok := obj.(*api.Service)
+		if !ok {
+			log.Debug().Msg("Failed to convert object to service, skipping")
+			continue
+		}
+		analyzeService(service, getServiceEndpointSlices(service), cfg)
+	}
+}
@@ -0,0 +1,109 @@
+package watcher
+
+import (
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var (
+	serviceInformerStopper chan struct{}
+	serviceInformer        cache.SharedIndexInformer
+	endpointSliceInformer  cache.SharedIndexInformer
+)
+
+// getServiceEndpointSlices returns the endpoint slices of the service from the informer cache
+func getServiceEndpointSlices(service *api.Service) []*discovery.EndpointSlice {
+	endpointSlices := make([]*discovery.EndpointSlice, 0)
+	if endpointSliceInformer == nil {
+		return endpointSlices
+	}
+
+	objs, err := endpointSliceInformer.GetIndexer().ByIndex(cache.NamespaceIndex, service.GetNamespace())
+	if err != nil {
+		return endpointSlices
+	}
+	for _, obj := range objs {
+		endpointSlice, ok := getDeletedObject(obj).(*discovery.EndpointSlice)
+		if ok && endpointSlice.GetLabels()[discovery.LabelServiceName] == service.GetName() {
+			endpointSlices = append(endpointSlices, endpointSlice)
+		}
+	}
+	return endpointSlices
+}
+
+func analyzeEndpointSliceService(endpointSlice *discovery.EndpointSlice, cfg *config.Config) {
+	serviceName := endpointSlice.GetLabels()[discovery.LabelServiceName]
+	if serviceName == "" || serviceInformer == nil {
+		return
+	}
+
+	obj, exists, err := serviceInformer.GetStore().GetByKey(endpointSlice.GetNamespace() + "/" + serviceName)
+	if err != nil || !exists {
+		return
+	}
+	service, ok := obj.(*api.Service)
+	if !ok {
+		return
+	}
+	analyzeService(service, getServiceEndpointSlices(service), cfg)
+}
+
+func startServiceInformer(cfg *config.Config) {
+	serviceInformer = scopedFactory.Core().V1().Services().Informer()
+	endpointSliceInformer = scopedFactory.Discovery().V1().EndpointSlices().Informer()
+	serviceInformerStopper = make(chan struct{})
+
+	serviceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			service, ok := getDeletedObject(obj).(*api.Service)
+			if !ok || !isInScope(service, cfg) {
+				return
+			}
+			log.Debug().Interface("service", service.GetName()).Msg("Delete Service")
+			analyzeServiceDeleted(service, cfg)
+		},
+	})
+	endpointSliceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			endpointSlice := newObj.(*discovery.EndpointSlice)
+			log.Debug().Interface("endpointslice", endpointSlice.GetName()).Msg("Update EndpointSlice")
+			analyzeEndpointSliceService(endpointSlice, cfg)
+		},
+		DeleteFunc: func(obj interface{}) {
+			endpointSlice, ok := getDeletedObject(obj).(*discovery.EndpointSlice)
+			if !ok {
+				return
+			}
+			log.Debug().Interface("endpointslice", endpointSlice.GetName()).Msg("Delete EndpointSlice")
+			analyzeEndpointSliceService(endpointSlice, cfg)
+		},
+	})
+
+	log.Info().Msg("Starting service informers")
+
+	defer memory.RecoverPanic("service-informer")
+	memory.SafeGo("endpointslice-informer", func() {
+		endpointSliceInformer.Run(serviceInformerStopper)
+	})
+	serviceInformer.Run(serviceInformerStopper)
+}
+
+func stopServiceInformer() {
+	if serviceInformerStopper != nil {
+		log.Info().Msg("Stopping service informers")
+		close(serviceInformerStopper)
+		serviceInformerStopper = nil
+	}
+}
+
+func GetServiceInformers() []cache.SharedInformer {
+	if serviceInformer == nil || endpointSliceInformer == nil {
+		return nil
+	}
+	return []cache.SharedInformer{serviceInformer, endpointSliceInformer}
+}
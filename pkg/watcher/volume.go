@@ -0,0 +1,292 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/commander"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// kubeletStatsSummary is the subset of the kubelet stats/summary response used for volume usage
+type kubeletStatsSummary struct {
+	Pods []kubeletPodStats `json:"pods"`
+}
+
+type kubeletPodStats struct {
+	PodRef  kubeletObjectRef     `json:"podRef"`
+	Volumes []kubeletVolumeStats `json:"volume"`
+}
+
+type kubeletObjectRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type kubeletVolumeStats struct {
+	Name           string            `json:"name"`
+	PVCRef         *kubeletObjectRef `json:"pvcRef"`
+	CapacityBytes  *uint64           `json:"capacityBytes"`
+	UsedBytes      *uint64           `json:"usedBytes"`
+	AvailableBytes *uint64           `json:"availableBytes"`
+	Inodes         *uint64           `json:"inodes"`
+	InodesUsed     *uint64           `json:"inodesUsed"`
+	InodesFree     *uint64           `json:"inodesFree"`
+}
+
+type volumeMount struct {
+	Pod      string
+	Workload *commander.WorkloadInfo
+}
+
+// AnnotationSelectedNode is set by the scheduler on a claim of a WaitForFirstConsumer storage class once a consuming pod is scheduled
+const AnnotationSelectedNode = "volume.kubernetes.io/selected-node"
+
+func getVolumeKey(namespace string, claimName string) string {
+	return fmt.Sprintf("%s/pvc/%s", namespace, claimName)
+}
+
+// getVolumeClaimPodName returns the first pod that mounts the persistent volume claim
+func getVolumeClaimPodName(kubeClient *kubernetes.Clientset, pvc *api.PersistentVolumeClaim) string {
+	pods, err := listNamespacePods(kubeClient, pvc.GetNamespace())
+	if err != nil {
+		log.Debug().Err(err).Str("pvc", pvc.GetName()).Str("namespace", pvc.GetNamespace()).Msg("Failed to get pvc pods")
+		return ""
+	}
+
+	for _, pod := range pods {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvc.GetName() {
+				return pod.GetName()
+			}
+		}
+	}
+	return ""
+}
+
+func getVolumeMount(kubeClient *kubernetes.Clientset, namespace string, podName string) *volumeMount {
+	if podName == "" {
+		return nil
+	}
+
+	mount := &volumeMount{Pod: podName}
+	workload, err, _ := commander.FindWorkloadByPodName(kubeClient, namespace, podName)
+	if err == nil {
+		mount.Workload = workload
+	}
+	return mount
+}
+
+func getEventLabelsFromVolume(namespace string, claimName string, mount *volumeMount) map[string]string {
+	labels := map[string]string{
+		"namespace": namespace,
+		"pvc":       claimName,
+	}
+	if mount != nil {
+		labels["pod"] = mount.Pod
+		if mount.Workload != nil {
+			labels["workloadType"] = string(mount.Workload.Type)
+			labels["workload"] = mount.Workload.Name
+		}
+	}
+	return labels
+}
+
+func getVolumeLinks(cfg *config.Config, namespace string, mount *volumeMount) []ilert.AlertLink {
+	if mount == nil || mount.Workload == nil {
+		return nil
+	}
+	return getWorkloadLinks(cfg, namespace, *mount.Workload)
+}
+
+func getVolumeMountDetails(mount *volumeMount) string {
+	if mount == nil {
+		return ""
+	}
+
+	details := fmt.Sprintf("\nPod: %s", mount.Pod)
+	if mount.Workload != nil {
+		details += fmt.Sprintf("\nWorkload: %s/%s", mount.Workload.Type, mount.Workload.Name)
+	}
+	return details
+}
+
+func getVolumeCustomDetails(claimName string, mount *volumeMount) map[string]interface{} {
+	customDetails := map[string]interface{}{
+		"pvc": claimName,
+	}
+	if mount != nil {
+		customDetails["pod"] = mount.Pod
+		if mount.Workload != nil {
+			customDetails["workload_type"] = string(mount.Workload.Type)
+			customDetails["workload_name"] = mount.Workload.Name
+		}
+	}
+	return customDetails
+}
+
+func getVolumeClaimDetails(pvc *api.PersistentVolumeClaim, mount *volumeMount) string {
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nPhase: %s\nVolume: %s\nCreated at: %s",
+		pvc.GetName(),
+		pvc.GetNamespace(),
+		pvc.Status.Phase,
+		pvc.Spec.VolumeName,
+		pvc.GetCreationTimestamp().Format(time.RFC3339))
+
+	if pvc.Spec.StorageClassName != nil {
+		details += fmt.Sprintf("\nStorage class: %s", *pvc.Spec.StorageClassName)
+	}
+	if storage, ok := pvc.Spec.Resources.Requests[api.ResourceStorage]; ok {
+		details += fmt.Sprintf("\nRequested storage: %s", storage.String())
+	}
+	details += getVolumeMountDetails(mount)
+	return details
+}
+
+func analyzeVolumeClaimStatus(pvc *api.PersistentVolumeClaim, cfg *config.Config) {
+	if !cfg.Alarms.Volumes.Enabled || pvc.GetDeletionTimestamp() != nil {
+		return
+	}
+
+	alertKey := getVolumeKey(pvc.GetNamespace(), pvc.GetName())
+	pvcName := fmt.Sprintf("%s/%s", pvc.GetNamespace(), pvc.GetName())
+
+	var setting config.ConfigAlarmSetting
+	switch {
+	case pvc.Status.Phase == api.ClaimPending && cfg.Alarms.Volumes.Pending.Enabled:
+		setting = config.ConfigAlarmSetting{Enabled: true, Priority: cfg.Alarms.Volumes.Pending.Priority}
+	case pvc.Status.Phase == api.ClaimLost && cfg.Alarms.Volumes.Lost.Enabled:
+		setting = cfg.Alarms.Volumes.Lost
+	}
+
+	if !setting.Enabled {
+		if clearProblemSince(alertKey) && cfg.Alarms.Volumes.SendResolveEvents {
+			labels := getEventLabelsFromVolume(pvc.GetNamespace(), pvc.GetName(), nil)
+			summary := fmt.Sprintf("Persistent volume claim %s is %s", pvcName, pvc.Status.Phase)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	// Claims of a WaitForFirstConsumer storage class stay pending by design until a pod is scheduled with them
+	podName := getVolumeClaimPodName(cfg.KubeClient, pvc)
+	if pvc.Status.Phase == api.ClaimPending && podName == "" && pvc.GetAnnotations()[AnnotationSelectedNode] == "" {
+		return
+	}
+
+	since := getProblemSince(alertKey)
+	if pvc.Status.Phase == api.ClaimPending && time.Since(pvc.GetCreationTimestamp().Time) < getDuration(cfg.Alarms.Volumes.Pending.Duration) {
+		return
+	}
+
+	mount := getVolumeMount(cfg.KubeClient, pvc.GetNamespace(), podName)
+	labels := getEventLabelsFromVolume(pvc.GetNamespace(), pvc.GetName(), mount)
+	labels["resourceVersion"] = pvc.GetResourceVersion()
+	summary := fmt.Sprintf("Persistent volume claim %s is %s", pvcName, pvc.Status.Phase)
+	if pvc.Status.Phase == api.ClaimPending {
+		summary = fmt.Sprintf("Persistent volume claim %s pending longer than %s", pvcName, cfg.Alarms.Volumes.Pending.Duration)
+	}
+	details := getVolumeClaimDetails(pvc, mount)
+	links := getVolumeLinks(cfg, pvc.GetNamespace(), mount)
+	customDetails := getVolumeCustomDetails(pvc.GetName(), mount)
+	customDetails["phase"] = string(pvc.Status.Phase)
+	customDetails["unhealthy_since"] = since.Format(time.RFC3339)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, setting.Priority, labels, links, nil, customDetails)
+}
+
+func getNodeVolumeStats(kubeClient *kubernetes.Clientset, nodeName string) (*kubeletStatsSummary, error) {
+	content, err := kubeClient.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("stats/summary").
+		DoRaw(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &kubeletStatsSummary{}
+	err = json.Unmarshal(content, summary)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func analyzeVolumeUsageSetting(cfg *config.Config, setting config.ConfigAlarmSettingWithThreshold, alertKey string, resource string, podRef kubeletObjectRef, claimRef kubeletObjectRef, used uint64, capacity uint64, formatValue func(uint64) string) {
+	if !setting.Enabled || capacity == 0 {
+		return
+	}
+
+	pvcName := fmt.Sprintf("%s/%s", claimRef.Namespace, claimRef.Name)
+	percentage := used * 100 / capacity
+	if percentage < uint64(setting.Threshold) {
+		if clearProblemSince(alertKey) && cfg.Alarms.Volumes.SendResolveEvents {
+			labels := getEventLabelsFromVolume(claimRef.Namespace, claimRef.Name, nil)
+			summary := fmt.Sprintf("Volume %s %s usage is below %d%%", pvcName, resource, setting.Threshold)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	since := getProblemSince(alertKey)
+	mount := getVolumeMount(cfg.KubeClient, podRef.Namespace, podRef.Name)
+	labels := getEventLabelsFromVolume(claimRef.Namespace, claimRef.Name, mount)
+	summary := fmt.Sprintf("Volume %s %s usage reached %d%%", pvcName, resource, percentage)
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nUsage: %s\nCapacity: %s\nThreshold: %d%%",
+		claimRef.Name,
+		claimRef.Namespace,
+		formatValue(used),
+		formatValue(capacity),
+		setting.Threshold)
+	details += getVolumeMountDetails(mount)
+	links := getVolumeLinks(cfg, claimRef.Namespace, mount)
+	customDetails := getVolumeCustomDetails(claimRef.Name, mount)
+	customDetails["resource"] = resource
+	customDetails["used"] = used
+	customDetails["capacity"] = capacity
+	customDetails["percentage"] = percentage
+	customDetails["threshold"] = setting.Threshold
+	customDetails["unhealthy_since"] = since.Format(time.RFC3339)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, setting.Priority, labels, links, nil, customDetails)
+}
+
+func formatInodes(value uint64) string {
+	return humanize.Comma(int64(value))
+}
+
+func analyzeNodeVolumes(nodeName string, cfg *config.Config) {
+	if !cfg.Alarms.Volumes.Enabled || (!cfg.Alarms.Volumes.Usage.Enabled && !cfg.Alarms.Volumes.Inodes.Enabled) {
+		return
+	}
+
+	summary, err := getNodeVolumeStats(cfg.KubeClient, nodeName)
+	if err != nil {
+		log.Debug().Err(err).Str("node", nodeName).Msg("Failed to get node volume stats")
+		return
+	}
+
+	for _, pod := range summary.Pods {
+		for _, volume := range pod.Volumes {
+			if volume.PVCRef == nil || !isNamespaceInScope(volume.PVCRef.Namespace, cfg) {
+				continue
+			}
+
+			alertKey := getVolumeKey(volume.PVCRef.Namespace, volume.PVCRef.Name)
+			if volume.UsedBytes != nil && volume.CapacityBytes != nil {
+				analyzeVolumeUsageSetting(cfg, cfg.Alarms.Volumes.Usage, fmt.Sprintf("%s-usage", alertKey), "disk", pod.PodRef, *volume.PVCRef, *volume.UsedBytes, *volume.CapacityBytes, humanize.IBytes)
+			}
+			if volume.InodesUsed != nil && volume.Inodes != nil {
+				analyzeVolumeUsageSetting(cfg, cfg.Alarms.Volumes.Inodes, fmt.Sprintf("%s-inodes", alertKey), "inodes", pod.PodRef, *volume.PVCRef, *volume.InodesUsed, *volume.Inodes, formatInodes)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var volumeCheckerCron *cron.Cron
+
+func startVolumeChecker(cfg *config.Config) {
+	volumeCheckerCron = cron.New()
+	volumeCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkVolumes(cfg)
+	})
+	// Every usage check proxies the kubelet stats of all nodes through the api server, it runs on a coarser schedule
+	if cfg.Alarms.Volumes.Usage.Enabled || cfg.Alarms.Volumes.Inodes.Enabled {
+		volumeCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Alarms.Volumes.CheckInterval), func() {
+			checkVolumesUsage(cfg)
+		})
+	}
+
+	log.Info().Msg("Starting volumes checker")
+	volumeCheckerCron.Start()
+}
+
+func stopVolumeChecker() {
+	if volumeCheckerCron != nil {
+		log.Info().Msg("Stopping volumes checker")
+		volumeCheckerCron.Stop()
+		volumeCheckerCron = nil
+	}
+}
+
+func checkVolumes(cfg *config.Config) {
+	defer memory.RecoverPanic("volume-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping volume check due to memory pressure")
+			return
+		}
+	}
+
+	informer := GetVolumeClaimInformer()
+	if informer == nil {
+		log.Warn().Msg("PVC informer not available, skipping volume check")
+		return
+	}
+
+	if !cache.WaitForCacheSync(nil, informer.HasSynced) {
+		log.Warn().Msg("PVC informer cache not synced, skipping volume check")
+		return
+	}
+
+	log.Debug().Msg("Running volumes check")
+
+	for _, obj := range informer.GetStore().List() {
+		pvc, ok := obj.(*api.PersistentVolumeClaim)
+		if !ok {
+			log.Debug().Msg("Failed to convert object to pvc, skipping")
+			continue
+		}
+		if !isInScope(pvc, cfg) {
+			continue
+		}
+		analyzeVolumeClaimStatus(pvc, cfg)
+	}
+}
+
+func checkVolumesUsage(cfg *config.Config) {
+	defer memory.RecoverPanic("volume-usage-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping volume usage check due to memory pressure")
+			return
+		}
+	}
+
+	nodes, err := listNodes(cfg.KubeClient)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get nodes, skipping volume usage check")
+		return
+	}
+
+	log.Debug().Msg("Running volumes usage check")
+
+	for _, node := range nodes {
+		analyzeNodeVolumes(node.GetName(), cfg)
+	}
+}
@@ -0,0 +1,47 @@
+package watcher
+
+import (
+	"github.com/rs/zerolog/log"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var (
+	volumeClaimInformerStopper chan struct{}
+	volumeClaimInformer        cache.SharedInformer
+)
+
+func startVolumeClaimInformer(cfg *config.Config) {
+	volumeClaimInformer = scopedFactory.Core().V1().PersistentVolumeClaims().Informer()
+	volumeClaimInformerStopper = make(chan struct{})
+	volumeClaimInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			pvc := newObj.(*api.PersistentVolumeClaim)
+			if !isInScope(pvc, cfg) {
+				return
+			}
+			log.Debug().Interface("pvc", pvc.GetName()).Msg("Update PersistentVolumeClaim")
+			analyzeVolumeClaimStatus(pvc, cfg)
+		},
+	})
+
+	log.Info().Msg("Starting pvc informer")
+
+	defer memory.RecoverPanic("pvc-informer")
+	volumeClaimInformer.Run(volumeClaimInformerStopper)
+}
+
+func stopVolumeClaimInformer() {
+	if volumeClaimInformerStopper != nil {
+		log.Info().Msg("Stopping pvc informer")
+		close(volumeClaimInformerStopper)
+		volumeClaimInformerStopper = nil
+	}
+}
+
+func GetVolumeClaimInformer() cache.SharedInformer {
+	return volumeClaimInformer
+}
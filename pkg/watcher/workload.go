@@ -0,0 +1,265 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iLert/ilert-go/v3"
+	"github.com/iLert/ilert-kube-agent/pkg/alert"
+	"github.com/iLert/ilert-kube-agent/pkg/commander"
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	apps "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+)
+
+// ProgressDeadlineExceeded is the deployment progressing condition reason of a stuck rollout
+const ProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+type workloadStatus struct {
+	Workload        commander.WorkloadInfo
+	Namespace       string
+	ResourceVersion string
+	Labels          map[string]string
+	Revision        string
+	Images          []string
+	Desired         int32
+	Ready           int32
+	Updated         int32
+	Available       int32
+	Unavailable     int32
+	Misscheduled    int32
+	Reason          string
+	Message         string
+	Since           time.Time
+}
+
+func getWorkloadKey(namespace string, workload commander.WorkloadInfo) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, workload.Type, workload.Name)
+}
+
+func getWorkloadKind(workloadType commander.WorkloadType) string {
+	switch workloadType {
+	case commander.WorkloadTypeDeployment:
+		return "Deployment"
+	case commander.WorkloadTypeStatefulSet:
+		return "StatefulSet"
+	case commander.WorkloadTypeDaemonSet:
+		return "DaemonSet"
+	}
+	return string(workloadType)
+}
+
+func getWorkloadImages(template api.PodTemplateSpec) []string {
+	images := make([]string, 0, len(template.Spec.Containers))
+	for _, container := range template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	return images
+}
+
+func getDeploymentStatus(deployment *apps.Deployment) *workloadStatus {
+	status := &workloadStatus{
+		Workload:        commander.WorkloadInfo{Type: commander.WorkloadTypeDeployment, Name: deployment.GetName()},
+		Namespace:       deployment.GetNamespace(),
+		ResourceVersion: deployment.GetResourceVersion(),
+		Labels:          deployment.GetLabels(),
+		Revision:        deployment.GetAnnotations()["deployment.kubernetes.io/revision"],
+		Images:          getWorkloadImages(deployment.Spec.Template),
+		Desired:         1,
+		Ready:           deployment.Status.ReadyReplicas,
+		Updated:         deployment.Status.UpdatedReplicas,
+		Available:       deployment.Status.AvailableReplicas,
+		Unavailable:     deployment.Status.UnavailableReplicas,
+	}
+	if deployment.Spec.Replicas != nil {
+		status.Desired = *deployment.Spec.Replicas
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == apps.DeploymentProgressing && condition.Reason == ProgressDeadlineExceeded {
+			status.Reason = condition.Reason
+			status.Message = condition.Message
+		}
+		if condition.Type == apps.DeploymentAvailable && condition.Status == api.ConditionFalse {
+			status.Since = condition.LastTransitionTime.Time
+		}
+	}
+	return status
+}
+
+func getStatefulSetStatus(statefulSet *apps.StatefulSet) *workloadStatus {
+	status := &workloadStatus{
+		Workload:        commander.WorkloadInfo{Type: commander.WorkloadTypeStatefulSet, Name: statefulSet.GetName()},
+		Namespace:       statefulSet.GetNamespace(),
+		ResourceVersion: statefulSet.GetResourceVersion(),
+		Labels:          statefulSet.GetLabels(),
+		Revision:        statefulSet.Status.UpdateRevision,
+		Images:          getWorkloadImages(statefulSet.Spec.Template),
+		Desired:         1,
+		Ready:           statefulSet.Status.ReadyReplicas,
+		Updated:         statefulSet.Status.UpdatedReplicas,
+		Available:       statefulSet.Status.AvailableReplicas,
+	}
+	if statefulSet.Spec.Replicas != nil {
+		status.Desired = *statefulSet.Spec.Replicas
+	}
+	if status.Desired > status.Available {
+		status.Unavailable = status.Desired - status.Available
+	}
+	return status
+}
+
+func getDaemonSetStatus(daemonSet *apps.DaemonSet) *workloadStatus {
+	return &workloadStatus{
+		Workload:        commander.WorkloadInfo{Type: commander.WorkloadTypeDaemonSet, Name: daemonSet.GetName()},
+		Namespace:       daemonSet.GetNamespace(),
+		ResourceVersion: daemonSet.GetResourceVersion(),
+		Labels:          daemonSet.GetLabels(),
+		Revision:        daemonSet.GetAnnotations()["deprecated.daemonset.template.generation"],
+		Images:          getWorkloadImages(daemonSet.Spec.Template),
+		Desired:         daemonSet.Status.DesiredNumberScheduled,
+		Ready:           daemonSet.Status.NumberReady,
+		Updated:         daemonSet.Status.UpdatedNumberScheduled,
+		Available:       daemonSet.Status.NumberAvailable,
+		Unavailable:     daemonSet.Status.NumberUnavailable,
+		Misscheduled:    daemonSet.Status.NumberMisscheduled,
+	}
+}
+
+func getEventLabelsFromWorkload(status *workloadStatus) map[string]string {
+	return alert.WithObjectLabels(map[string]string{
+		"namespace":                  status.Namespace,
+		"resourceVersion":            status.ResourceVersion,
+		"workloadType":               string(status.Workload.Type),
+		string(status.Workload.Type): status.Workload.Name,
+	}, status.Labels)
+}
+
+func getWorkloadMustacheValues(namespace string, workload commander.WorkloadInfo) map[string]string {
+	return map[string]string{
+		"workload_namespace": namespace,
+		"workload_name":      workload.Name,
+		"workload_type":      string(workload.Type),
+	}
+}
+
+func getWorkloadLinks(cfg *config.Config, namespace string, workload commander.WorkloadInfo) []ilert.AlertLink {
+	return renderLinks(cfg.Links.Workloads, getWorkloadMustacheValues(namespace, workload))
+}
+
+func getWorkloadDetails(status *workloadStatus) string {
+	details := fmt.Sprintf("Name: %s\nNamespace: %s\nType: %s\nRevision: %s\nImages: %s\nDesired replicas: %d\nReady replicas: %d\nUpdated replicas: %d\nAvailable replicas: %d\nUnavailable replicas: %d",
+		status.Workload.Name,
+		status.Namespace,
+		status.Workload.Type,
+		status.Revision,
+		strings.Join(status.Images, ", "),
+		status.Desired,
+		status.Ready,
+		status.Updated,
+		status.Available,
+		status.Unavailable)
+
+	if status.Workload.Type == commander.WorkloadTypeDaemonSet {
+		details += fmt.Sprintf("\nMisscheduled: %d", status.Misscheduled)
+	}
+	if status.Reason != "" {
+		details += fmt.Sprintf("\nReason: %s\nMessage: %s", status.Reason, status.Message)
+	}
+	return details
+}
+
+func getWorkloadCustomDetails(status *workloadStatus) map[string]interface{} {
+	customDetails := map[string]interface{}{
+		"workload_type":        string(status.Workload.Type),
+		"workload_name":        status.Workload.Name,
+		"revision":             status.Revision,
+		"images":               status.Images,
+		"desired_replicas":     status.Desired,
+		"ready_replicas":       status.Ready,
+		"updated_replicas":     status.Updated,
+		"available_replicas":   status.Available,
+		"unavailable_replicas": status.Unavailable,
+	}
+	if status.Workload.Type == commander.WorkloadTypeDaemonSet {
+		customDetails["misscheduled"] = status.Misscheduled
+	}
+	if status.Reason != "" {
+		customDetails["reason"] = status.Reason
+		customDetails["message"] = status.Message
+	}
+	return customDetails
+}
+
+func getWorkloadSetting(cfg *config.Config, workloadType commander.WorkloadType) config.ConfigAlarmSettingWorkload {
+	switch workloadType {
+	case commander.WorkloadTypeDeployment:
+		return cfg.Alarms.Workloads.Deployments
+	case commander.WorkloadTypeStatefulSet:
+		return cfg.Alarms.Workloads.StatefulSets
+	case commander.WorkloadTypeDaemonSet:
+		return cfg.Alarms.Workloads.DaemonSets
+	}
+	return config.ConfigAlarmSettingWorkload{}
+}
+
+func analyzeWorkloadStatus(status *workloadStatus, cfg *config.Config) {
+	setting := getWorkloadSetting(cfg, status.Workload.Type)
+	if !cfg.Alarms.Workloads.Enabled || !setting.Enabled {
+		return
+	}
+
+	alertKey := getWorkloadKey(status.Namespace, status.Workload)
+	labels := getEventLabelsFromWorkload(status)
+	workloadName := fmt.Sprintf("%s/%s", status.Namespace, status.Workload.Name)
+
+	problem := ""
+	if status.Reason == ProgressDeadlineExceeded {
+		problem = fmt.Sprintf("rollout stuck - %s", status.Reason)
+	} else if status.Unavailable >= setting.Threshold {
+		problem = fmt.Sprintf("%d/%d replicas unavailable", status.Unavailable, status.Desired)
+	} else if status.Misscheduled >= setting.Threshold {
+		problem = fmt.Sprintf("%d pods misscheduled", status.Misscheduled)
+	}
+
+	if problem == "" {
+		if clearProblemSince(alertKey) && cfg.Alarms.Workloads.SendResolveEvents {
+			summary := fmt.Sprintf("%s %s recovered", getWorkloadKind(status.Workload.Type), workloadName)
+			alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", labels, nil, nil, nil)
+		}
+		return
+	}
+
+	// The available condition of a deployment outlives agent restarts and run once mode
+	since := getProblemSince(alertKey)
+	if !status.Since.IsZero() && status.Since.Before(since) {
+		since = status.Since
+	}
+	// The progress deadline of a deployment already includes the waiting duration
+	if status.Reason != ProgressDeadlineExceeded && time.Since(since) < getDuration(setting.Duration) {
+		return
+	}
+
+	summary := fmt.Sprintf("%s %s %s", getWorkloadKind(status.Workload.Type), workloadName, problem)
+	details := getWorkloadDetails(status)
+	links := getWorkloadLinks(cfg, status.Namespace, status.Workload)
+	customDetails := getWorkloadCustomDetails(status)
+	customDetails["unhealthy_since"] = since.Format(time.RFC3339)
+	alert.CreateEvent(cfg, alertKey, summary, details, ilert.EventTypes.Alert, setting.Priority, labels, links, nil, customDetails)
+}
+
+// analyzeWorkloadDeleted resolves the alert of the removed workload
+func analyzeWorkloadDeleted(status *workloadStatus, cfg *config.Config) {
+	alertKey := getWorkloadKey(status.Namespace, status.Workload)
+	if clearProblemSince(alertKey) && cfg.Alarms.Workloads.SendResolveEvents {
+		summary := fmt.Sprintf("%s %s/%s deleted", getWorkloadKind(status.Workload.Type), status.Namespace, status.Workload.Name)
+		alert.CreateEvent(cfg, alertKey, summary, "", ilert.EventTypes.Resolve, "", getEventLabelsFromWorkload(status), nil, nil, nil)
+	}
+}
+
+func analyzeDeployment(deployment *apps.Deployment, cfg *config.Config) {
+	if deployment.Spec.Paused {
+		return
+	}
+	analyzeWorkloadStatus(getDeploymentStatus(deployment), cfg)
+}
@@ -0,0 +1,79 @@
+package watcher
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var workloadCheckerCron *cron.Cron
+
+func startWorkloadChecker(cfg *config.Config) {
+	workloadCheckerCron = cron.New()
+	workloadCheckerCron.AddFunc(fmt.Sprintf("@every %s", cfg.Settings.CheckInterval), func() {
+		checkWorkloads(cfg)
+	})
+
+	log.Info().Msg("Starting workloads checker")
+	workloadCheckerCron.Start()
+}
+
+func stopWorkloadChecker() {
+	if workloadCheckerCron != nil {
+		log.Info().Msg("Stopping workloads checker")
+		workloadCheckerCron.Stop()
+		workloadCheckerCron = nil
+	}
+}
+
+func checkWorkloads(cfg *config.Config) {
+	defer memory.RecoverPanic("workload-checker")
+
+	if memory.GetGlobalMonitor() != nil && memory.GetGlobalMonitor().IsUnderPressure() {
+		pressureLevel := memory.GetGlobalMonitor().GetPressureLevel()
+		if pressureLevel == "critical" || pressureLevel == "emergency" {
+			log.Warn().Str("pressure_level", pressureLevel).Msg("Skipping workload check due to memory pressure")
+			return
+		}
+	}
+
+	informers := GetWorkloadInformers()
+	if informers == nil {
+		log.Warn().Msg("Workload informers not available, skipping workload check")
+		return
+	}
+
+	for _, informer := range informers {
+		if !cache.WaitForCacheSync(nil, informer.HasSynced) {
+			log.Warn().Msg("Workload informers cache not synced, skipping workload check")
+			return
+		}
+	}
+
+	log.Debug().Msg("Running workloads check")
+
+	for _, informer := range informers {
+		for _, obj := range informer.GetStore().List() {
+			if workload, ok := obj.(metav1.Object); ok && !isInScope(workload, cfg) {
+				continue
+			}
+			switch workload := obj.(type) {
+			case *apps.Deployment:
+				analyzeDeployment(workload, cfg)
+			case *apps.StatefulSet:
+				analyzeWorkloadStatus(getStatefulSetStatus(workload), cfg)
+			case *apps.DaemonSet:
+				analyzeWorkloadStatus(getDaemonSetStatus(workload), cfg)
+			default:
+				log.Debug().Msg("Failed to convert object to workload, skipping")
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package watcher
+
+import (
+	"github.com/rs/zerolog/log"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/iLert/ilert-kube-agent/pkg/config"
+	"github.com/iLert/ilert-kube-agent/pkg/memory"
+)
+
+var (
+	workloadInformerStopper chan struct{}
+	deploymentInformer      cache.SharedInformer
+	statefulSetInformer     cache.SharedInformer
+	daemonSetInformer       cache.SharedInformer
+)
+
+func startWorkloadInformer(cfg *config.Config) {
+	deploymentInformer = scopedFactory.Apps().V1().Deployments().Informer()
+	statefulSetInformer = scopedFactory.Apps().V1().StatefulSets().Informer()
+	daemonSetInformer = scopedFactory.Apps().V1().DaemonSets().Informer()
+	workloadInformerStopper = make(chan struct{})
+
+	deploymentInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			deployment := newObj.(*apps.Deployment)
+			if !isInScope(deployment, cfg) {
+				return
+			}
+			log.Debug().Interface("deployment", deployment.GetName()).Msg("Update Deployment")
+			analyzeDeployment(deployment, cfg)
+		},
+		DeleteFunc: func(obj interface{}) {
+			deployment, ok := getDeletedObject(obj).(*apps.Deployment)
+			if !ok || !isInScope(deployment, cfg) {
+				return
+			}
+			log.Debug().Interface("deployment", deployment.GetName()).Msg("Delete Deployment")
+			analyzeWorkloadDeleted(getDeploymentStatus(deployment), cfg)
+		},
+	})
+	statefulSetInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			statefulSet := newObj.(*apps.StatefulSet)
+			if !isInScope(statefulSet, cfg) {
+				return
+			}
+			log.Debug().Interface("statefulset", statefulSet.GetName()).Msg("Update StatefulSet")
+			analyzeWorkloadStatus(getStatefulSetStatus(statefulSet), cfg)
+		},
+		DeleteFunc: func(obj interface{}) {
+			statefulSet, ok := getDeletedObject(obj).(*apps.StatefulSet)
+			if !ok || !isInScope(statefulSet, cfg) {
+				return
+			}
+			log.Debug().Interface("statefulset", statefulSet.GetName()).Msg("Delete StatefulSet")
+			analyzeWorkloadDeleted(getStatefulSetStatus(statefulSet), cfg)
+		},
+	})
+	daemonSetInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			daemonSet := newObj.(*apps.DaemonSet)
+			if !isInScope(daemonSet, cfg) {
+				return
+			}
+			log.Debug().Interface("daemonset", daemonSet.GetName()).Msg("Update DaemonSet")
+			analyzeWorkloadStatus(getDaemonSetStatus(daemonSet), cfg)
+		},
+		DeleteFunc: func(obj interface{}) {
+			daemonSet, ok := getDeletedObject(obj).(*apps.DaemonSet)
+			if !ok || !isInScope(daemonSet, cfg) {
+				return
+			}
+			log.Debug().Interface("daemonset", daemonSet.GetName()).Msg("Delete DaemonSet")
+			analyzeWorkloadDeleted(getDaemonSetStatus(daemonSet), cfg)
+		},
+	})
+
+	log.Info().Msg("Starting workload informers")
+
+	defer memory.RecoverPanic("workload-informer")
+	memory.SafeGo("deployment-informer", func() {
+		deploymentInformer.Run(workloadInformerStopper)
+	})
+	memory.SafeGo("statefulset-informer", func() {
+		statefulSetInformer.Run(workloadInformerStopper)
+	})
+	daemonSetInformer.Run(workloadInformerStopper)
+}
+
+func stopWorkloadInformer() {
+	if workloadInformerStopper != nil {
+		log.Info().Msg("Stopping workload informers")
+		close(workloadInformerStopper)
+		workloadInformerStopper = nil
+	}
+}
+
+func GetWorkloadInformers() []cache.SharedInformer {
+	if deploymentInformer == nil || statefulSetInformer == nil || daemonSetInformer == nil {
+		return nil
+	}
+	return []cache.SharedInformer{deploymentInformer, statefulSetInformer, daemonSetInformer}
+}